@@ -65,7 +65,11 @@ func main() {
 				r.Post("/ingest/start", h.PostIngestStart)
 				r.Post("/ingest/stop", h.PostIngestStop)
 				r.Get("/ingest/status", h.GetIngestStatus)
+				r.Post("/ingest/pause", h.PostIngestPause)
+				r.Post("/ingest/resume", h.PostIngestResume)
+				r.Post("/ingest/seek", h.PostIngestSeek)
 				r.Post("/audio/upload", h.PostAudioUpload)
+				r.Get("/audio/snapshot", h.GetAudioSnapshot)
 			})
 		})
 
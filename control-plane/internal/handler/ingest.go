@@ -22,12 +22,12 @@ func (h *Handlers) PostIngestStart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
 		return
 	}
-	if req.URL == "" {
+	if req.URL == "" && req.Type == "" {
 		http.Error(w, `{"error":"url is required"}`, http.StatusBadRequest)
 		return
 	}
 
-	reqBody, _ := json.Marshal(map[string]string{"url": req.URL})
+	reqBody, _ := json.Marshal(req)
 	gwResp, err := h.httpClient.Post(
 		fmt.Sprintf("%s/internal/sessions/%s/ingest/start", h.GatewayBaseURL, sessionID),
 		"application/json",
@@ -78,6 +78,51 @@ func (h *Handlers) GetIngestStatus(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, gwResp.Body)
 }
 
+// PostIngestPause handles POST /v1/sessions/{sessionId}/ingest/pause.
+func (h *Handlers) PostIngestPause(w http.ResponseWriter, r *http.Request) {
+	h.proxyIngestControl(w, r, "pause", nil)
+}
+
+// PostIngestResume handles POST /v1/sessions/{sessionId}/ingest/resume.
+func (h *Handlers) PostIngestResume(w http.ResponseWriter, r *http.Request) {
+	h.proxyIngestControl(w, r, "resume", nil)
+}
+
+// PostIngestSeek handles POST /v1/sessions/{sessionId}/ingest/seek.
+func (h *Handlers) PostIngestSeek(w http.ResponseWriter, r *http.Request) {
+	var req model.IngestSeekRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PositionSec == nil || *req.PositionSec < 0 {
+		http.Error(w, `{"error":"positionSec must be a number >= 0"}`, http.StatusBadRequest)
+		return
+	}
+
+	reqBody, _ := json.Marshal(req)
+	h.proxyIngestControl(w, r, "seek", reqBody)
+}
+
+// proxyIngestControl forwards a pause, resume or seek to the gateway and
+// returns its response (the ingest status, or an error).
+func (h *Handlers) proxyIngestControl(w http.ResponseWriter, r *http.Request, action string, body []byte) {
+	sessionID := chi.URLParam(r, "sessionId")
+
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost,
+		fmt.Sprintf("%s/internal/sessions/%s/ingest/%s", h.GatewayBaseURL, sessionID, action),
+		bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	gwResp, err := h.httpClient.Do(req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"gateway unavailable: %s"}`, err), http.StatusBadGateway)
+		return
+	}
+	defer gwResp.Body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(gwResp.StatusCode)
+	io.Copy(w, gwResp.Body)
+}
+
 // PostAudioUpload handles POST /v1/sessions/{sessionId}/audio/upload.
 // Proxies raw audio bytes to the gateway for decoding and ring buffer write.
 func (h *Handlers) PostAudioUpload(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ingestControlRouter routes the ingest controls to h.
+func ingestControlRouter(h *Handlers) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/v1/sessions/{sessionId}/ingest/pause", h.PostIngestPause)
+	r.Post("/v1/sessions/{sessionId}/ingest/resume", h.PostIngestResume)
+	r.Post("/v1/sessions/{sessionId}/ingest/seek", h.PostIngestSeek)
+	return r
+}
+
+func TestIngestControl_PassesThroughConflicts(t *testing.T) {
+	t.Parallel()
+
+	for action, gwError := range map[string]string{
+		"pause":  `{"error":"ingest source is not seekable"}`,
+		"resume": `{"error":"ingest is not running"}`,
+	} {
+		var gotPath string
+		gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(gwError))
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessions/s1/ingest/"+action, nil)
+		rec := httptest.NewRecorder()
+		ingestControlRouter(NewHandlers(gw.URL)).ServeHTTP(rec, req)
+		gw.Close()
+
+		if want := "/internal/sessions/s1/ingest/" + action; gotPath != want {
+			t.Errorf("%s: gateway path: got %q want %q", action, gotPath, want)
+		}
+		if rec.Code != http.StatusConflict {
+			t.Errorf("%s: status: got %d want 409", action, rec.Code)
+		}
+		if rec.Body.String() != gwError {
+			t.Errorf("%s: body: got %q want %q", action, rec.Body.String(), gwError)
+		}
+	}
+}
+
+func TestPostIngestSeek_ForwardsPosition(t *testing.T) {
+	t.Parallel()
+
+	var gotPath, gotBody, gotType string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody, gotType = r.URL.Path, string(body), r.Header.Get("Content-Type")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"state":"running","seekable":true,"positionSec":90.5}`))
+	}))
+	defer gw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/s1/ingest/seek",
+		strings.NewReader(`{"positionSec":90.5}`))
+	rec := httptest.NewRecorder()
+	ingestControlRouter(NewHandlers(gw.URL)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d want 200", rec.Code)
+	}
+	if want := "/internal/sessions/s1/ingest/seek"; gotPath != want {
+		t.Errorf("gateway path: got %q want %q", gotPath, want)
+	}
+	if want := `{"positionSec":90.5}`; gotBody != want || gotType != "application/json" {
+		t.Errorf("gateway body: got %q (%s) want %q", gotBody, gotType, want)
+	}
+	if !strings.Contains(rec.Body.String(), `"positionSec":90.5`) {
+		t.Errorf("body: got %q", rec.Body.String())
+	}
+}
+
+func TestPostIngestSeek_InvalidPosition(t *testing.T) {
+	t.Parallel()
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid seek forwarded to the gateway: %s", r.URL.Path)
+	}))
+	defer gw.Close()
+
+	for _, body := range []string{`{}`, `{"positionSec":-1}`, `{"positionSec":"10"}`, `not json`} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessions/s1/ingest/seek", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		ingestControlRouter(NewHandlers(gw.URL)).ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status: got %d want 400", body, rec.Code)
+		}
+	}
+}
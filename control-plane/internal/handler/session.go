@@ -34,11 +34,24 @@ func NewHandlers(gatewayBaseURL string) *Handlers {
 
 // CreateSession handles POST /v1/sessions.
 // Generates a UUID, calls the gateway to create a WebRTC session, and returns the SDP offer.
+// The body is optional; encoderProfile and asrPreprocessing are forwarded to the gateway.
 func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateSessionRequest
+	if body, _ := io.ReadAll(r.Body); len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, `{"error":"invalid JSON body"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
 	sessionID := uuid.New().String()
 
 	// Call gateway internal API
-	reqBody, _ := json.Marshal(map[string]string{"sessionId": sessionID})
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"sessionId":        sessionID,
+		"encoderProfile":   req.EncoderProfile,
+		"asrPreprocessing": req.ASRPreprocessing,
+	})
 	gwResp, err := h.httpClient.Post(
 		h.GatewayBaseURL+"/internal/sessions",
 		"application/json",
@@ -50,6 +63,13 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	}
 	defer gwResp.Body.Close()
 
+	if gwResp.StatusCode == http.StatusBadRequest {
+		// Invalid session options — the gateway's JSON error is meant for the caller.
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		io.Copy(w, gwResp.Body)
+		return
+	}
 	if gwResp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(gwResp.Body)
 		http.Error(w, fmt.Sprintf(`{"error":"gateway error: %s"}`, string(body)), http.StatusBadGateway)
@@ -86,3 +106,31 @@ func (h *Handlers) DeleteSession(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetAudioSnapshot handles GET /v1/sessions/{sessionId}/audio/snapshot.
+// Proxies to the gateway, forwarding query params (fromMs, toMs, format) and
+// streaming the WAV / Ogg body back with its content headers.
+func (h *Handlers) GetAudioSnapshot(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionId")
+
+	gwURL := fmt.Sprintf("%s/internal/sessions/%s/audio/snapshot", h.GatewayBaseURL, sessionID)
+	if r.URL.RawQuery != "" {
+		gwURL += "?" + r.URL.RawQuery
+	}
+	gwReq, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, gwURL, nil)
+
+	gwResp, err := h.httpClient.Do(gwReq)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"gateway unavailable: %s"}`, err), http.StatusBadGateway)
+		return
+	}
+	defer gwResp.Body.Close()
+
+	for _, k := range []string{"Content-Type", "Content-Length", "Content-Disposition", "X-Capture-Start"} {
+		if v := gwResp.Header.Get(k); v != "" {
+			w.Header().Set(k, v)
+		}
+	}
+	w.WriteHeader(gwResp.StatusCode)
+	io.Copy(w, gwResp.Body)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateSession_ForwardsOptions(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]json.RawMessage
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sdpOffer":"v=0","iceServers":[]}`))
+	}))
+	defer gw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions",
+		strings.NewReader(`{"encoderProfile":{"preset":"music","bitrate":96000},"asrPreprocessing":{"normalize":false}}`))
+	rec := httptest.NewRecorder()
+	NewHandlers(gw.URL).CreateSession(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status: got %d want 201", rec.Code)
+	}
+	if want := `{"preset":"music","bitrate":96000}`; string(got["encoderProfile"]) != want {
+		t.Errorf("forwarded encoderProfile: got %s want %s", got["encoderProfile"], want)
+	}
+	if want := `{"normalize":false}`; string(got["asrPreprocessing"]) != want {
+		t.Errorf("forwarded asrPreprocessing: got %s want %s", got["asrPreprocessing"], want)
+	}
+	if len(got["sessionId"]) == 0 {
+		t.Error("sessionId not sent to gateway")
+	}
+}
+
+func TestCreateSession_InvalidEncoderProfile(t *testing.T) {
+	t.Parallel()
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid encoderProfile: unknown preset \"loud\""}`))
+	}))
+	defer gw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions",
+		strings.NewReader(`{"encoderProfile":{"preset":"loud"}}`))
+	rec := httptest.NewRecorder()
+	NewHandlers(gw.URL).CreateSession(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status: got %d want 400", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "unknown preset") {
+		t.Errorf("body: got %q", rec.Body.String())
+	}
+}
+
+func TestGetAudioSnapshot_ProxiesToGateway(t *testing.T) {
+	t.Parallel()
+
+	var gotPath, gotQuery string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Header().Set("Content-Disposition", `attachment; filename="s1.wav"`)
+		w.Write([]byte("RIFF"))
+	}))
+	defer gw.Close()
+
+	r := chi.NewRouter()
+	r.Get("/v1/sessions/{sessionId}/audio/snapshot", NewHandlers(gw.URL).GetAudioSnapshot)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/sessions/s1/audio/snapshot?fromMs=100&toMs=900&format=wav", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d want 200", rec.Code)
+	}
+	if want := "/internal/sessions/s1/audio/snapshot"; gotPath != want {
+		t.Errorf("gateway path: got %q want %q", gotPath, want)
+	}
+	if want := "fromMs=100&toMs=900&format=wav"; gotQuery != want {
+		t.Errorf("gateway query: got %q want %q", gotQuery, want)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "audio/wav" {
+		t.Errorf("content-type: got %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got == "" {
+		t.Error("content-disposition not forwarded")
+	}
+	if rec.Body.String() != "RIFF" {
+		t.Errorf("body: got %q", rec.Body.String())
+	}
+}
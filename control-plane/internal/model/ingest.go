@@ -2,14 +2,62 @@ package model
 
 // IngestStartRequest is the request body for POST /v1/sessions/{sessionId}/ingest/start.
 type IngestStartRequest struct {
-	URL string `json:"url"`
+	Type  string            `json:"type,omitempty"`
+	URL   string            `json:"url,omitempty"`
+	Relay bool              `json:"relay,omitempty"`
+	HLS   *IngestHLSOptions `json:"hls,omitempty"`
+	// Reconnect overrides the gateway's reconnect policy for dropped streams.
+	Reconnect *IngestReconnectPolicy `json:"reconnect,omitempty"`
+}
+
+// IngestReconnectPolicy controls how a dropped ingest stream is restarted.
+// Unset fields keep the gateway defaults.
+type IngestReconnectPolicy struct {
+	MaxAttempts      *int     `json:"maxAttempts,omitempty"`
+	InitialBackoffMs *int     `json:"initialBackoffMs,omitempty"`
+	MaxBackoffMs     *int     `json:"maxBackoffMs,omitempty"`
+	Jitter           *float64 `json:"jitter,omitempty"`
+}
+
+// IngestHLSOptions selects the rendition of an HLS ingest.
+type IngestHLSOptions struct {
+	Language     string `json:"language,omitempty"`
+	MaxBandwidth int    `json:"maxBandwidth,omitempty"`
 }
 
 // IngestStatusResponse is the response for GET /v1/sessions/{sessionId}/ingest/status.
 type IngestStatusResponse struct {
-	State           string  `json:"state"`
-	SourceURL       string  `json:"sourceUrl"`
-	SecondsBuffered float64 `json:"secondsBuffered"`
-	BytesRead       int64   `json:"bytesRead"`
-	LastError       string  `json:"lastError,omitempty"`
+	Type            string              `json:"type,omitempty"`
+	State           string              `json:"state"`
+	SourceURL       string              `json:"sourceUrl"`
+	SecondsBuffered float64             `json:"secondsBuffered"`
+	BytesRead       int64               `json:"bytesRead"`
+	LastError       string              `json:"lastError,omitempty"`
+	Relaying        bool                `json:"relaying,omitempty"`
+	SegmentsFetched int64               `json:"segmentsFetched,omitempty"`
+	LiveLatencySec  float64             `json:"liveLatencySec,omitempty"`
+	Reconnects      int                 `json:"reconnects,omitempty"`
+	ErrorHistory    []IngestErrorRecord `json:"errorHistory,omitempty"`
+	Metadata        *IngestMetadata     `json:"metadata,omitempty"`
+	Seekable        bool                `json:"seekable,omitempty"`
+	PositionSec     float64             `json:"positionSec,omitempty"`
+}
+
+// IngestSeekRequest is the request body for POST /v1/sessions/{sessionId}/ingest/seek.
+type IngestSeekRequest struct {
+	PositionSec *float64 `json:"positionSec"`
+}
+
+// IngestMetadata is what the ingested stream says is playing.
+type IngestMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Artist      string `json:"artist,omitempty"`
+	StreamTitle string `json:"streamTitle,omitempty"`
+}
+
+// IngestErrorRecord is one past failure of an ingest source.
+type IngestErrorRecord struct {
+	Time    string `json:"time"`
+	Error   string `json:"error"`
+	Attempt int    `json:"attempt,omitempty"`
 }
@@ -1,5 +1,14 @@
 package model
 
+import "encoding/json"
+
+// CreateSessionRequest is the optional request body for POST /v1/sessions.
+// Its options are passed through to the gateway, which validates them.
+type CreateSessionRequest struct {
+	EncoderProfile   json.RawMessage `json:"encoderProfile,omitempty"`
+	ASRPreprocessing json.RawMessage `json:"asrPreprocessing,omitempty"`
+}
+
 type CreateSessionResponse struct {
 	SessionID  string      `json:"sessionId"`
 	SdpOffer   string      `json:"sdpOffer"`
@@ -1,7 +1,8 @@
 """gRPC servicer adapter for the ASR service."""
 
 import logging
-import time
+import queue
+import threading
 
 from whats.v1 import asr_pb2, asr_pb2_grpc
 
@@ -15,6 +16,38 @@ class AsrServicer(asr_pb2_grpc.AsrServiceServicer):
         self.asr = asr_service
 
     def Transcribe(self, request, context):
+        return self._transcribe(request)
+
+    def TranscribeStream(self, request, context):
+        """Stream partial hypotheses while Whisper decodes, then the final result."""
+        partials = queue.Queue()
+        outcome = {}
+
+        def on_partial(text, language):
+            partials.put(asr_pb2.TranscribePartial(text=text, language=language))
+
+        def run():
+            try:
+                outcome["final"] = self._transcribe(request, on_partial=on_partial)
+            except Exception as e:  # surfaced to the client below
+                outcome["error"] = e
+            finally:
+                partials.put(None)
+
+        threading.Thread(target=run, daemon=True).start()
+
+        while True:
+            partial = partials.get()
+            if partial is None:
+                break
+            if context.is_active():
+                yield asr_pb2.TranscribeStreamResponse(partial=partial)
+
+        if "error" in outcome:
+            raise outcome["error"]
+        yield asr_pb2.TranscribeStreamResponse(final=outcome["final"])
+
+    def _transcribe(self, request, on_partial=None):
         # Detect text-only translation mode:
         # When audio is empty and language_hint starts with "source_text:",
         # extract the source text and language for NLLB-only translation.
@@ -53,6 +86,7 @@ class AsrServicer(asr_pb2_grpc.AsrServiceServicer):
             task=request.task or "transcribe",
             target_language=request.target_language or None,
             source_text=source_text,
+            on_partial=on_partial,
         )
 
         segments = [
@@ -2,6 +2,7 @@
 
 import logging
 import time
+from collections.abc import Callable
 
 import numpy as np
 
@@ -63,6 +64,7 @@ class ASRService:
         target_language: str | None = None,
         translate_timeout_ms: int = 250,
         source_text: str | None = None,
+        on_partial: Callable[[str, str], None] | None = None,
     ) -> dict:
         """Transcribe PCM s16le audio bytes and optionally translate.
 
@@ -73,6 +75,8 @@ class ASRService:
             task: "transcribe" or "translate" (to English via Whisper).
             target_language: If set, translate text to this language via NLLB.
             translate_timeout_ms: Timeout for NLLB translation.
+            on_partial: Optional callback invoked with (text_so_far, language)
+                after each decoded segment, for streaming partial results.
 
         Returns:
             dict with "text", "language", "segments", "inference_duration_ms",
@@ -143,6 +147,8 @@ class ASRService:
                 "confidence": getattr(seg, "avg_log_prob", 0.0),
             })
             text_parts.append(seg.text.strip())
+            if on_partial:
+                on_partial(" ".join(text_parts), info.language)
 
         # Fallback: if VAD stripped everything, retry WITHOUT VAD.
         # This handles music where vocals are mixed too quietly for Silero VAD.
@@ -20,7 +20,7 @@ class TTSConfig:
     models_dir: str = os.getenv("TTS_MODELS_DIR", "/app/models")
     device: str = os.getenv("TTS_DEVICE", "cpu")
     num_workers: int = int(os.getenv("NUM_WORKERS", "2"))
-    # Output format: PCM s16le, 16kHz, mono (canonical format)
-    sample_rate: int = 16000
+    # Output format: PCM s16le mono at each voice's native sample rate,
+    # declared per stream in SynthesizeResponse.format.
     channels: int = 1
     sample_width: int = 2  # bytes per sample (s16le)
@@ -4,6 +4,8 @@ import logging
 
 from whats.v1 import common_pb2, tts_pb2, tts_pb2_grpc
 
+from tts.service import CHUNK_MS
+
 logger = logging.getLogger(__name__)
 
 
@@ -23,20 +25,30 @@ class TtsServicer(tts_pb2_grpc.TtsServiceServicer):
             request.language or "en",
         )
 
+        voice = request.voice or "default"
+        language = request.language or "en"
+        # Declared once, on the first response; the gateway keeps it for the stream.
+        fmt = common_pb2.AudioFormat(
+            sample_rate=self.tts.sample_rate(voice, language),
+            channels=1,
+            encoding=common_pb2.AUDIO_ENCODING_PCM_S16LE,
+        )
+
         seq = 0
         for pcm_chunk in self.tts.synthesize(
             text=request.text,
-            voice=request.voice or "default",
+            voice=voice,
             speed=request.speed if request.speed > 0 else 1.0,
-            language=request.language or "en",
+            language=language,
         ):
             yield tts_pb2.SynthesizeResponse(
                 chunk=common_pb2.AudioChunk(
                     data=pcm_chunk,
                     sequence=seq,
-                    duration_ms=100,  # 3200 bytes = 100ms at 16kHz
+                    duration_ms=CHUNK_MS,
                     is_final=False,
                 ),
+                format=fmt if seq == 0 else None,
             )
             seq += 1
 
@@ -5,15 +5,18 @@ import time
 from collections.abc import Iterator
 from pathlib import Path
 
-import numpy as np
-
 from tts.config import DEFAULT_VOICE, VOICE_MAP
 
 logger = logging.getLogger(__name__)
 
-# Chunk size in bytes for streaming TTS output.
-# At 16kHz s16le mono: 1600 samples = 100ms = 3200 bytes.
-CHUNK_SIZE_BYTES = 3200
+# Duration of each streamed TTS chunk. Chunks are PCM s16le mono at the
+# voice's native sample rate (e.g. 22050 Hz → 2205 samples = 4410 bytes).
+CHUNK_MS = 100
+
+
+def chunk_size_bytes(sample_rate: int) -> int:
+    """Bytes in one CHUNK_MS chunk of s16le mono audio at sample_rate."""
+    return sample_rate * CHUNK_MS // 1000 * 2
 
 
 class TTSService:
@@ -67,10 +70,16 @@ class TTSService:
         # Last resort: first loaded voice.
         return next(iter(self.voices))
 
+    def sample_rate(self, voice: str = "default", language: str = "en") -> int:
+        """Native sample rate of the voice synthesize() would use."""
+        return self.native_rates[self._resolve_voice(voice, language)]
+
     def synthesize(
         self, text: str, voice: str = "default", speed: float = 1.0, language: str = "en"
     ) -> Iterator[bytes]:
-        """Synthesize text to PCM s16le 16kHz mono audio chunks.
+        """Synthesize text to PCM s16le mono audio chunks at the voice's native rate.
+
+        The gateway resamples to its output rate; see sample_rate().
 
         Args:
             text: Text to synthesize.
@@ -79,7 +88,7 @@ class TTSService:
             language: BCP-47 language code for voice auto-selection.
 
         Yields:
-            bytes: PCM audio chunks of CHUNK_SIZE_BYTES each.
+            bytes: PCM audio chunks of chunk_size_bytes(native rate) each.
         """
         if not text.strip():
             return
@@ -94,33 +103,22 @@ class TTSService:
         length_scale = 1.0 / speed if speed > 0 else 1.0
         syn_config = self.SynthesisConfig(length_scale=length_scale)
 
+        chunk_bytes = chunk_size_bytes(native_rate)
         residual = b""
 
         for audio_chunk in piper_voice.synthesize(text, syn_config=syn_config):
             # audio_chunk.audio_int16_bytes is raw int16 PCM at native_rate
-            pcm = np.frombuffer(audio_chunk.audio_int16_bytes, dtype=np.int16)
-
-            # Resample to 16kHz if needed
-            if native_rate != 16000:
-                n_out = int(len(pcm) * 16000 / native_rate)
-                if n_out == 0:
-                    continue
-                x_old = np.arange(len(pcm))
-                x_new = np.linspace(0, len(pcm) - 1, n_out)
-                pcm_resampled = np.interp(x_new, x_old, pcm.astype(np.float64))
-                pcm = np.clip(pcm_resampled, -32768, 32767).astype(np.int16)
-
-            raw_16k = residual + pcm.tobytes()
+            raw = residual + audio_chunk.audio_int16_bytes
             residual = b""
 
             offset = 0
-            while offset + CHUNK_SIZE_BYTES <= len(raw_16k):
-                yield raw_16k[offset : offset + CHUNK_SIZE_BYTES]
-                offset += CHUNK_SIZE_BYTES
+            while offset + chunk_bytes <= len(raw):
+                yield raw[offset : offset + chunk_bytes]
+                offset += chunk_bytes
 
-            if offset < len(raw_16k):
-                residual = raw_16k[offset:]
+            if offset < len(raw):
+                residual = raw[offset:]
 
         # Yield final residual padded with silence
         if residual:
-            yield residual + b"\x00" * (CHUNK_SIZE_BYTES - len(residual))
+            yield residual + b"\x00" * (chunk_bytes - len(residual))
@@ -1,6 +1,6 @@
 """Tests for the TTS service."""
 
-from tts.service import CHUNK_SIZE_BYTES, TTSService
+from tts.service import TTSService, chunk_size_bytes
 
 
 def test_synthesize_yields_chunks():
@@ -9,4 +9,4 @@ def test_synthesize_yields_chunks():
     assert len(chunks) >= 1
     for chunk in chunks:
         assert isinstance(chunk, bytes)
-        assert len(chunk) == CHUNK_SIZE_BYTES
+        assert len(chunk) == chunk_size_bytes(svc.sample_rate())
@@ -6,6 +6,7 @@ require (
 	github.com/RenatoCabral2022/WhatsWebService/gen/go v0.0.0
 	github.com/hraban/opus v0.0.0-20251117090126-c76ea7e21bf3
 	github.com/pion/interceptor v0.1.37
+	github.com/pion/rtp v1.8.11
 	github.com/pion/webrtc/v4 v4.0.10
 	github.com/prometheus/client_golang v1.20.5
 	go.uber.org/zap v1.27.0
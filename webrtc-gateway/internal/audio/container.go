@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+)
+
+// WriteWAV writes mono s16le PCM at sampleRate as a canonical 44-byte-header WAV file.
+func WriteWAV(w io.Writer, pcm []byte, sampleRate int) error {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	blockAlign := channels * bitsPerSample / 8
+
+	var hdr [44]byte
+	copy(hdr[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(36+len(pcm)))
+	copy(hdr[8:12], "WAVE")
+	copy(hdr[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(hdr[16:20], 16) // fmt chunk size
+	binary.LittleEndian.PutUint16(hdr[20:22], 1)  // PCM
+	binary.LittleEndian.PutUint16(hdr[22:24], channels)
+	binary.LittleEndian.PutUint32(hdr[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(hdr[28:32], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(hdr[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(hdr[34:36], bitsPerSample)
+	copy(hdr[36:40], "data")
+	binary.LittleEndian.PutUint32(hdr[40:44], uint32(len(pcm)))
+
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(pcm)
+	return err
+}
+
+// WriteOggOpus encodes 16kHz mono s16le PCM to Opus and writes it as an Ogg/Opus
+// stream. The last frame is zero-padded to a full 20ms.
+func WriteOggOpus(w io.Writer, pcm16k []byte) error {
+	enc, err := NewEncoder(VoiceProfile)
+	if err != nil {
+		return fmt.Errorf("create opus encoder: %w", err)
+	}
+	ogg, err := oggwriter.NewWith(w, OpusSampleRate, OpusChannels)
+	if err != nil {
+		return fmt.Errorf("create ogg writer: %w", err)
+	}
+
+	const frameBytes16k = SamplesPerFrame / 3 * 2 // 20ms of 16kHz s16le
+	frame := make([]byte, frameBytes16k)
+	upsampler := NewResampler(PCMSampleRate, OpusSampleRate)
+	pcm48 := make([]int16, SamplesPerFrame)
+	buf := make([]byte, 1024)
+
+	for i := 0; len(pcm16k) > 0; i++ {
+		n := copy(frame, pcm16k)
+		clear(frame[n:])
+		pcm16k = pcm16k[n:]
+
+		opusData, err := enc.EncodeInto(upsampler.ProcessInto(BytesToInt16(frame), pcm48), buf)
+		if err != nil {
+			return fmt.Errorf("encode opus frame: %w", err)
+		}
+		if err := ogg.WriteRTP(&rtp.Packet{
+			Header: rtp.Header{
+				SequenceNumber: uint16(i),
+				Timestamp:      uint32(i * SamplesPerFrame),
+			},
+			Payload: opusData,
+		}); err != nil {
+			return err
+		}
+	}
+	return ogg.Close()
+}
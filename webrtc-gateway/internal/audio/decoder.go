@@ -38,6 +38,15 @@ func (d *Decoder) DecodeInto(opusData []byte, pcm []int16) (int, error) {
 	return d.dec.Decode(opusData, pcm)
 }
 
+// DecodeFECInto reconstructs the frame lost before opusData from the FEC data it
+// carries, filling all of pcm (whose length must be the lost frame's duration).
+func (d *Decoder) DecodeFECInto(opusData []byte, pcm []int16) (int, error) {
+	if err := d.dec.DecodeFEC(opusData, pcm); err != nil {
+		return 0, err
+	}
+	return len(pcm), nil
+}
+
 // DecodePLC performs packet loss concealment for a missing frame.
 func (d *Decoder) DecodePLC(expectedSamples int) ([]int16, error) {
 	pcm := make([]int16, expectedSamples)
@@ -1,6 +1,10 @@
 package audio
 
-import "github.com/hraban/opus"
+import (
+	"fmt"
+
+	"github.com/hraban/opus"
+)
 
 const (
 	FrameDurationMs = 20
@@ -8,39 +12,149 @@ const (
 	EncoderBitrate  = 32000
 )
 
+// Opus application modes for EncoderProfile.Application.
+const (
+	ApplicationVoIP  = "voip"
+	ApplicationAudio = "audio"
+)
+
+// EncoderProfile configures the outbound Opus encoder of a session.
+type EncoderProfile struct {
+	Application string // ApplicationVoIP or ApplicationAudio
+	Bitrate     int    // bits per second
+	Complexity  int    // 0-10
+	FEC         bool   // in-band forward error correction
+	DTX         bool   // discontinuous transmission during silence
+	// DualMono sends the mono signal in both channels of a two-channel Opus
+	// stream, for clients that handle stereo better. It adds no stereo image.
+	DualMono bool
+}
+
+// VoiceProfile suits speech. It keeps the voip application and bitrate of the
+// encoder used before profiles existed, but sets complexity 10 where that one
+// left the libopus default.
+var VoiceProfile = EncoderProfile{
+	Application: ApplicationVoIP,
+	Bitrate:     EncoderBitrate,
+	Complexity:  10,
+}
+
+// MusicProfile suits music and lyrics playback. Playback audio is mono, so it
+// stays mono: a second channel would only spend bits on a copy. There is no
+// true stereo profile, as nothing the session plays has a stereo image.
+var MusicProfile = EncoderProfile{
+	Application: ApplicationAudio,
+	Bitrate:     128000,
+	Complexity:  10,
+}
+
+// ProfileByName returns a predefined profile ("voice" or "music").
+func ProfileByName(name string) (EncoderProfile, bool) {
+	switch name {
+	case "voice":
+		return VoiceProfile, true
+	case "music":
+		return MusicProfile, true
+	}
+	return EncoderProfile{}, false
+}
+
+// Validate checks that p is within the ranges libopus accepts.
+func (p EncoderProfile) Validate() error {
+	if p.Application != ApplicationVoIP && p.Application != ApplicationAudio {
+		return fmt.Errorf("application must be %q or %q", ApplicationVoIP, ApplicationAudio)
+	}
+	if p.Bitrate < 6000 || p.Bitrate > 510000 {
+		return fmt.Errorf("bitrate must be between 6000 and 510000")
+	}
+	if p.Complexity < 0 || p.Complexity > 10 {
+		return fmt.Errorf("complexity must be between 0 and 10")
+	}
+	return nil
+}
+
+// Channels returns the number of channels the profile encodes.
+func (p EncoderProfile) Channels() int {
+	if p.DualMono {
+		return 2
+	}
+	return 1
+}
+
 // Encoder wraps hraban/opus to encode 48kHz int16 PCM to Opus.
+// Callers always pass mono frames; a dual-mono profile duplicates them into both channels.
 // Not thread-safe — use one per session.
 type Encoder struct {
-	enc *opus.Encoder
+	enc     *opus.Encoder
+	profile EncoderProfile
+	dualBuf []int16
 }
 
-func NewEncoder() (*Encoder, error) {
-	enc, err := opus.NewEncoder(OpusSampleRate, OpusChannels, opus.AppVoIP)
+// NewEncoder creates an encoder configured by p.
+func NewEncoder(p EncoderProfile) (*Encoder, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	app := opus.AppVoIP
+	if p.Application == ApplicationAudio {
+		app = opus.AppAudio
+	}
+	enc, err := opus.NewEncoder(OpusSampleRate, p.Channels(), app)
 	if err != nil {
 		return nil, err
 	}
-	if err := enc.SetBitrate(EncoderBitrate); err != nil {
+	if err := enc.SetBitrate(p.Bitrate); err != nil {
+		return nil, err
+	}
+	if err := enc.SetComplexity(p.Complexity); err != nil {
+		return nil, err
+	}
+	if err := enc.SetInBandFEC(p.FEC); err != nil {
 		return nil, err
 	}
-	return &Encoder{enc: enc}, nil
+	if err := enc.SetDTX(p.DTX); err != nil {
+		return nil, err
+	}
+
+	e := &Encoder{enc: enc, profile: p}
+	if p.DualMono {
+		e.dualBuf = make([]int16, 2*SamplesPerFrame)
+	}
+	return e, nil
+}
+
+// Profile returns the profile the encoder was created with.
+func (e *Encoder) Profile() EncoderProfile {
+	return e.profile
 }
 
-// Encode converts a 960-sample (20ms at 48kHz) int16 frame to Opus bytes.
+// Encode converts a 960-sample (20ms at 48kHz) mono int16 frame to Opus bytes.
 func (e *Encoder) Encode(pcm []int16) ([]byte, error) {
-	buf := make([]byte, 1024)
-	n, err := e.enc.Encode(pcm, buf)
-	if err != nil {
-		return nil, err
-	}
-	return buf[:n], nil
+	return e.EncodeInto(pcm, make([]byte, 1024))
 }
 
 // EncodeInto encodes into a caller-provided buffer, avoiding allocation.
 // Returns the used portion of buf.
 func (e *Encoder) EncodeInto(pcm []int16, buf []byte) ([]byte, error) {
+	if e.dualBuf != nil {
+		pcm = e.upmix(pcm)
+	}
 	n, err := e.enc.Encode(pcm, buf)
 	if err != nil {
 		return nil, err
 	}
 	return buf[:n], nil
 }
+
+// upmix interleaves a mono frame into both channels of dualBuf.
+func (e *Encoder) upmix(mono []int16) []int16 {
+	if cap(e.dualBuf) < 2*len(mono) {
+		e.dualBuf = make([]int16, 2*len(mono))
+	}
+	out := e.dualBuf[:2*len(mono)]
+	for i, s := range mono {
+		out[2*i] = s
+		out[2*i+1] = s
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package audio
+
+import "testing"
+
+func TestEncoderProfileValidate(t *testing.T) {
+	for _, p := range []EncoderProfile{VoiceProfile, MusicProfile} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+
+	bad := []func(*EncoderProfile){
+		func(p *EncoderProfile) { p.Application = "lowdelay" },
+		func(p *EncoderProfile) { p.Bitrate = 1000 },
+		func(p *EncoderProfile) { p.Bitrate = 600000 },
+		func(p *EncoderProfile) { p.Complexity = 11 },
+	}
+	for i, mutate := range bad {
+		p := VoiceProfile
+		mutate(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("case %d: expected error for %+v", i, p)
+		}
+	}
+}
+
+func TestEncoderDualMonoUpmix(t *testing.T) {
+	if MusicProfile.Channels() != 1 {
+		t.Errorf("music profile encodes %d channels, want mono", MusicProfile.Channels())
+	}
+	p := MusicProfile
+	p.DualMono = true
+	enc, err := NewEncoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono := make([]int16, SamplesPerFrame)
+	for i := range mono {
+		mono[i] = int16(i)
+	}
+	out := enc.upmix(mono)
+	if len(out) != 2*SamplesPerFrame {
+		t.Fatalf("got %d samples, want %d", len(out), 2*SamplesPerFrame)
+	}
+	for i, s := range mono {
+		if out[2*i] != s || out[2*i+1] != s {
+			t.Fatalf("frame %d: got (%d, %d), want (%d, %d)", i, out[2*i], out[2*i+1], s, s)
+		}
+	}
+}
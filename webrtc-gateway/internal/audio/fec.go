@@ -0,0 +1,153 @@
+package audio
+
+// OpusPacketHasFEC reports whether an Opus packet carries in-band FEC (SILK LBRR
+// data) for the frame before it. Only SILK and hybrid packets can; the flag is
+// read from the start of the first frame's range-coded SILK header (RFC 6716 §4.2.3).
+func OpusPacketHasFEC(pkt []byte) bool {
+	if len(pkt) < 2 {
+		return false
+	}
+	config := int(pkt[0] >> 3)
+	if config >= 16 { // CELT-only
+		return false
+	}
+
+	// SILK frames per Opus frame: 10/20ms → 1, 40ms → 2, 60ms → 3.
+	silkFrames := 1
+	if config < 12 {
+		silkFrames = max(1, config%4)
+	}
+
+	frame, ok := firstOpusFrame(pkt)
+	if !ok || len(frame) == 0 {
+		return false
+	}
+
+	// Mid channel header: one VAD flag per SILK frame, then the LBRR flag,
+	// each coded with probability 1/2.
+	d := newRangeDecoder(frame)
+	for i := 0; i < silkFrames; i++ {
+		d.bitLogp(1)
+	}
+	return d.bitLogp(1)
+}
+
+// firstOpusFrame returns the compressed data of the first frame in pkt
+// (RFC 6716 §3.2).
+func firstOpusFrame(pkt []byte) ([]byte, bool) {
+	data := pkt[1:]
+	switch pkt[0] & 0x3 {
+	case 0, 1:
+		return data, true
+	case 2:
+		n, size, ok := opusFrameLength(data)
+		if !ok || len(data) < n+size {
+			return nil, false
+		}
+		return data[n : n+size], true
+	}
+
+	// Code 3: frame count byte, optional padding length, optional VBR lengths.
+	if len(data) < 1 {
+		return nil, false
+	}
+	vbr, padded, frames := data[0]&0x80 != 0, data[0]&0x40 != 0, int(data[0]&0x3f)
+	data = data[1:]
+	if padded {
+		for {
+			if len(data) < 1 {
+				return nil, false
+			}
+			b := data[0]
+			data = data[1:]
+			if b != 255 {
+				break
+			}
+		}
+	}
+	if !vbr {
+		return data, true
+	}
+	// The first frame follows all M-1 length fields.
+	firstSize := -1
+	for i := 0; i < frames-1; i++ {
+		n, size, ok := opusFrameLength(data)
+		if !ok {
+			return nil, false
+		}
+		if firstSize < 0 {
+			firstSize = size
+		}
+		data = data[n:]
+	}
+	if firstSize < 0 || len(data) < firstSize {
+		return nil, false
+	}
+	return data[:firstSize], true
+}
+
+// opusFrameLength decodes a 1- or 2-byte frame length, returning the number of
+// bytes it occupied and the length.
+func opusFrameLength(data []byte) (n, size int, ok bool) {
+	if len(data) < 1 {
+		return 0, 0, false
+	}
+	if data[0] < 252 {
+		return 1, int(data[0]), true
+	}
+	if len(data) < 2 {
+		return 0, 0, false
+	}
+	return 2, int(data[0]) + 4*int(data[1]), true
+}
+
+// rangeDecoder is just enough of the Opus range decoder (RFC 6716 §4.1) to read
+// the leading flags of a SILK frame.
+type rangeDecoder struct {
+	buf []byte
+	pos int
+	rng uint32
+	val uint32
+	rem int
+}
+
+func newRangeDecoder(buf []byte) *rangeDecoder {
+	d := &rangeDecoder{buf: buf, rng: 128}
+	d.rem = d.next()
+	d.val = 127 - uint32(d.rem>>1)
+	d.normalize()
+	return d
+}
+
+func (d *rangeDecoder) next() int {
+	if d.pos >= len(d.buf) {
+		return 0
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return int(b)
+}
+
+func (d *rangeDecoder) normalize() {
+	for d.rng <= 1<<23 {
+		d.rng <<= 8
+		sym := d.rem
+		d.rem = d.next()
+		sym = (sym<<8 | d.rem) >> 1
+		d.val = ((d.val << 8) + uint32(255&^sym)) & 0x7fffffff
+	}
+}
+
+// bitLogp decodes one bit whose probability of being set is 1/2^logp.
+func (d *rangeDecoder) bitLogp(logp uint) bool {
+	s := d.rng >> logp
+	bit := d.val < s
+	if bit {
+		d.rng = s
+	} else {
+		d.val -= s
+		d.rng -= s
+	}
+	d.normalize()
+	return bit
+}
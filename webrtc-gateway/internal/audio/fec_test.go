@@ -0,0 +1,29 @@
+package audio
+
+import "testing"
+
+func TestOpusPacketHasFEC(t *testing.T) {
+	// With equiprobable flags the first two header bits of a mono 20ms SILK
+	// frame are the top two bits of its first byte: VAD, then LBRR.
+	const silk20 = 1 << 3 // config 1: SILK NB 20ms, code 0
+	tests := []struct {
+		name string
+		pkt  []byte
+		want bool
+	}{
+		{"voiced with LBRR", []byte{silk20, 0xc0, 0x00}, true},
+		{"voiced without LBRR", []byte{silk20, 0x80, 0x00}, false},
+		{"unvoiced with LBRR", []byte{silk20, 0x40, 0x00}, true},
+		{"unvoiced without LBRR", []byte{silk20, 0x00, 0x00}, false},
+		{"CELT-only", []byte{31 << 3, 0xff, 0xff}, false},
+		{"code 2 second frame", []byte{silk20 | 2, 1, 0x00, 0xc0}, false},
+		{"code 3 VBR", []byte{silk20 | 3, 0x82, 1, 0xc0, 0x00}, true},
+		{"code 3 padded CBR", []byte{silk20 | 3, 0x42, 2, 0xc0, 0x00, 0, 0}, true},
+		{"truncated", []byte{silk20}, false},
+	}
+	for _, tt := range tests {
+		if got := OpusPacketHasFEC(tt.pkt); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Encoding identifies how samples are laid out in an audio byte stream.
+type Encoding int
+
+const (
+	// EncodingPCMS16LE is signed 16-bit little-endian PCM.
+	EncodingPCMS16LE Encoding = iota
+	// EncodingPCMF32LE is 32-bit float little-endian PCM in [-1.0, 1.0].
+	EncodingPCMF32LE
+	// EncodingOpus is one complete Opus packet per chunk.
+	EncodingOpus
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingPCMS16LE:
+		return "pcm_s16le"
+	case EncodingPCMF32LE:
+		return "pcm_f32le"
+	case EncodingOpus:
+		return "opus"
+	}
+	return fmt.Sprintf("encoding(%d)", int(e))
+}
+
+// Format describes an audio stream. Multi-channel PCM is interleaved.
+type Format struct {
+	SampleRate int
+	Channels   int
+	Encoding   Encoding
+}
+
+// PCM16kMono is the canonical format used by the ring buffer and ASR.
+var PCM16kMono = Format{SampleRate: PCMSampleRate, Channels: 1, Encoding: EncodingPCMS16LE}
+
+func (f Format) String() string {
+	if f.Encoding == EncodingOpus {
+		return fmt.Sprintf("opus/%dch", f.Channels)
+	}
+	return fmt.Sprintf("%s/%dHz/%dch", f.Encoding, f.SampleRate, f.Channels)
+}
+
+// Validate reports whether f can be converted for playback.
+func (f Format) Validate() error {
+	switch f.Encoding {
+	case EncodingPCMS16LE, EncodingPCMF32LE:
+		if f.SampleRate < 8000 || f.SampleRate > 192000 {
+			return fmt.Errorf("audio: unsupported sample rate %d", f.SampleRate)
+		}
+	case EncodingOpus:
+	default:
+		return fmt.Errorf("audio: unsupported encoding %s", f.Encoding)
+	}
+	if f.Channels < 1 || f.Channels > 8 {
+		return fmt.Errorf("audio: unsupported channel count %d", f.Channels)
+	}
+	return nil
+}
+
+// frameBytes is the size of one sample across all channels, or 0 for Opus.
+func (f Format) frameBytes() int {
+	switch f.Encoding {
+	case EncodingPCMS16LE:
+		return 2 * f.Channels
+	case EncodingPCMF32LE:
+		return 4 * f.Channels
+	}
+	return 0
+}
+
+// Duration returns the playback length of data in this format. For Opus, data
+// must be a single packet; malformed packets count as zero.
+func (f Format) Duration(data []byte) time.Duration {
+	if f.Encoding == EncodingOpus {
+		n, err := OpusPacketSamples(data)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(n) * time.Second / OpusSampleRate
+	}
+	fb := f.frameBytes()
+	if fb == 0 || f.SampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(len(data)/fb) * time.Second / time.Duration(f.SampleRate)
+}
+
+// Chunk is a piece of an audio stream tagged with its format.
+type Chunk struct {
+	Data   []byte
+	Format Format
+}
+
+var errBadOpusPacket = errors.New("audio: malformed opus packet")
+
+// OpusPacketSamples returns the number of 48kHz samples in an Opus packet,
+// from its TOC byte (RFC 6716 §3.1).
+func OpusPacketSamples(pkt []byte) (int, error) {
+	if len(pkt) == 0 {
+		return 0, errBadOpusPacket
+	}
+	config := int(pkt[0] >> 3)
+	var frameSamples int
+	switch {
+	case config < 12: // SILK-only: 10, 20, 40, 60ms
+		frameSamples = []int{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid: 10, 20ms
+		frameSamples = []int{480, 960}[config%2]
+	default: // CELT-only: 2.5, 5, 10, 20ms
+		frameSamples = []int{120, 240, 480, 960}[config%4]
+	}
+
+	frames := 1
+	switch pkt[0] & 0x3 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(pkt) < 2 {
+			return 0, errBadOpusPacket
+		}
+		frames = int(pkt[1] & 0x3f)
+	}
+	if n := frames * frameSamples; frames > 0 && n <= MaxFrameSize {
+		return n, nil
+	}
+	return 0, errBadOpusPacket
+}
+
+// PlaybackConverter turns audio in any supported Format into 48kHz mono int16
+// ready for the Opus encoder: it decodes, downmixes and resamples as needed.
+// Partial samples split across chunks are carried over.
+// Not thread-safe — use one per stream.
+type PlaybackConverter struct {
+	format    Format
+	dec       *Decoder
+	resampler *Resampler // nil when the source is already 48kHz
+	pending   []byte
+	decodeBuf []int16
+}
+
+// NewPlaybackConverter returns a converter from f to 48kHz mono int16.
+func NewPlaybackConverter(f Format) (*PlaybackConverter, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	c := &PlaybackConverter{format: f}
+	if f.Encoding == EncodingOpus {
+		// A mono decoder downmixes stereo streams itself and always runs at 48kHz.
+		dec, err := NewDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("audio: create opus decoder: %w", err)
+		}
+		c.dec = dec
+		c.decodeBuf = make([]int16, MaxFrameSize)
+		return c, nil
+	}
+	if f.SampleRate != OpusSampleRate {
+		c.resampler = NewResampler(f.SampleRate, OpusSampleRate)
+	}
+	return c, nil
+}
+
+// Format returns the source format the converter was created for.
+func (c *PlaybackConverter) Format() Format {
+	return c.format
+}
+
+// Convert returns data as 48kHz mono samples. The result is freshly allocated.
+func (c *PlaybackConverter) Convert(data []byte) ([]int16, error) {
+	if c.dec != nil {
+		n, err := c.dec.DecodeInto(data, c.decodeBuf)
+		if err != nil {
+			return nil, fmt.Errorf("audio: decode opus: %w", err)
+		}
+		out := make([]int16, n)
+		copy(out, c.decodeBuf[:n])
+		return out, nil
+	}
+
+	if len(c.pending) > 0 {
+		data = append(c.pending, data...)
+		c.pending = nil
+	}
+	fb := c.format.frameBytes()
+	whole := len(data) / fb * fb
+	if whole < len(data) {
+		c.pending = append([]byte(nil), data[whole:]...)
+	}
+	mono := c.downmix(data[:whole])
+	if c.resampler == nil {
+		return mono, nil
+	}
+	return c.resampler.Process(mono), nil
+}
+
+// downmix decodes whole sample frames to mono int16, averaging channels.
+func (c *PlaybackConverter) downmix(data []byte) []int16 {
+	ch := c.format.Channels
+	fb := c.format.frameBytes()
+	out := make([]int16, len(data)/fb)
+	for i := range out {
+		frame := data[i*fb : (i+1)*fb]
+		var sum float32
+		for j := 0; j < ch; j++ {
+			if c.format.Encoding == EncodingPCMF32LE {
+				sum += math.Float32frombits(binary.LittleEndian.Uint32(frame[j*4:])) * 32767
+			} else {
+				sum += float32(int16(binary.LittleEndian.Uint16(frame[j*2:])))
+			}
+		}
+		out[i] = clampInt16(sum / float32(ch))
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOpusPacketSamples(t *testing.T) {
+	tests := []struct {
+		pkt  []byte
+		want int
+	}{
+		{[]byte{1<<3 | 0}, 960},         // SILK 20ms, one frame
+		{[]byte{3<<3 | 1}, 2 * 2880},    // SILK 60ms, two frames
+		{[]byte{13<<3 | 0}, 960},        // Hybrid 20ms
+		{[]byte{16<<3 | 0}, 120},        // CELT 2.5ms
+		{[]byte{31<<3 | 3, 3}, 3 * 960}, // CELT 20ms, code 3 with three frames
+	}
+	for _, tt := range tests {
+		got, err := OpusPacketSamples(tt.pkt)
+		if err != nil || got != tt.want {
+			t.Errorf("TOC %#x: got %d (%v), want %d", tt.pkt[0], got, err, tt.want)
+		}
+	}
+	for _, bad := range [][]byte{nil, {31<<3 | 3}, {31<<3 | 3, 0}, {31<<3 | 3, 7}} {
+		if _, err := OpusPacketSamples(bad); err == nil {
+			t.Errorf("packet %v: expected error", bad)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	if d := PCM16kMono.Duration(make([]byte, 3200)); d != 100*time.Millisecond {
+		t.Errorf("16k s16le: got %v, want 100ms", d)
+	}
+	f32 := Format{SampleRate: 24000, Channels: 2, Encoding: EncodingPCMF32LE}
+	if d := f32.Duration(make([]byte, 24000*8/10)); d != 100*time.Millisecond {
+		t.Errorf("24k f32 stereo: got %v, want 100ms", d)
+	}
+}
+
+func TestPlaybackConverterF32Stereo(t *testing.T) {
+	// 22.05kHz float stereo, right channel silent, split mid-sample across chunks.
+	f := Format{SampleRate: 22050, Channels: 2, Encoding: EncodingPCMF32LE}
+	const n = 22050 / 2
+	data := make([]byte, n*8)
+	for i := 0; i < n; i++ {
+		v := float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/22050))
+		binary.LittleEndian.PutUint32(data[i*8:], math.Float32bits(v))
+	}
+
+	c, err := NewPlaybackConverter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []int16
+	for off := 0; off < len(data); off += 1001 {
+		s, err := c.Convert(data[off:min(off+1001, len(data))])
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, s...)
+	}
+
+	if want := n * OpusSampleRate / 22050; len(out) < want-2 || len(out) > want+2 {
+		t.Fatalf("got %d samples at 48kHz, want ~%d", len(out), want)
+	}
+	var peak int16
+	for _, s := range out[len(out)/4:] {
+		peak = max(peak, s)
+	}
+	// Downmixing averages the silent channel in: 0.5 * 32767 / 2.
+	if want := int16(32767 / 4); peak < want-300 || peak > want+300 {
+		t.Errorf("peak %d, want ~%d", peak, want)
+	}
+}
+
+func TestPlaybackConverterRejectsBadFormat(t *testing.T) {
+	for _, f := range []Format{
+		{SampleRate: 0, Channels: 1, Encoding: EncodingPCMS16LE},
+		{SampleRate: 16000, Channels: 0, Encoding: EncodingPCMS16LE},
+		{SampleRate: 16000, Channels: 1, Encoding: Encoding(9)},
+	} {
+		if _, err := NewPlaybackConverter(f); err == nil {
+			t.Errorf("%s: expected error", f)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package audio
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// MixerRole decides how an input interacts with ducking.
+type MixerRole int
+
+const (
+	// Foreground inputs (spoken TTS) play at full level and duck background
+	// inputs while they have audio queued.
+	Foreground MixerRole = iota
+	// Background inputs (a relayed live source) are attenuated under foreground audio.
+	Background
+)
+
+const (
+	// mixerQueueMs bounds how far a writer can run ahead of playback.
+	mixerQueueMs = 200
+	// duckAttackMs and duckReleaseMs are the time constants of the duck gain
+	// going down and coming back up.
+	duckAttackMs  = 30.0
+	duckReleaseMs = 400.0
+	// duckHoldMs keeps the background ducked across short gaps in foreground
+	// audio (between TTS chunks or words) so it does not pump.
+	duckHoldMs = 300
+)
+
+// Mixer combines several 48kHz mono PCM inputs into one stream of frames for a
+// single Opus encoder. Writers are paced by the reader: Write blocks while an
+// input already holds mixerQueueMs of audio. Safe for concurrent use.
+type Mixer struct {
+	mu      sync.Mutex
+	closed  bool
+	inputs  []*MixerInput
+	ready   chan struct{} // signalled when audio is written
+	limiter *limiter
+
+	duckGain  float64 // background level while ducked (linear)
+	duck      float64 // current smoothed background gain
+	attack    float64 // per-sample smoothing coefficients
+	release   float64
+	holdLeft  int // samples the duck is held after foreground audio ends
+	holdTotal int
+}
+
+// NewMixer returns a mixer that ducks background inputs by duckDB (negative)
+// under foreground audio.
+func NewMixer(duckDB float64) *Mixer {
+	coef := func(ms float64) float64 { return 1 - math.Exp(-1/(ms/1000*OpusSampleRate)) }
+	return &Mixer{
+		ready:     make(chan struct{}, 1),
+		limiter:   newLimiter(OpusSampleRate),
+		duckGain:  math.Pow(10, min(duckDB, 0)/20),
+		duck:      1,
+		attack:    coef(duckAttackMs),
+		release:   coef(duckReleaseMs),
+		holdTotal: OpusSampleRate * duckHoldMs / 1000,
+	}
+}
+
+// Ready is signalled after audio is written to an idle mixer, so a paced reader
+// can sleep while there is nothing to play.
+func (m *Mixer) Ready() <-chan struct{} {
+	return m.ready
+}
+
+// SetDuckLevel changes how far background inputs are attenuated (dB, negative).
+func (m *Mixer) SetDuckLevel(duckDB float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.duckGain = math.Pow(10, min(duckDB, 0)/20)
+}
+
+// AddInput attaches a new input. Close it when the producer is done. After the
+// mixer is closed the input is returned already closed.
+func (m *Mixer) AddInput(role MixerRole) *MixerInput {
+	in := &MixerInput{
+		m:     m,
+		role:  role,
+		space: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	m.mu.Lock()
+	closed := m.closed
+	if !closed {
+		m.inputs = append(m.inputs, in)
+	}
+	m.mu.Unlock()
+	if closed {
+		in.Close()
+	}
+	return in
+}
+
+// Close closes every input, unblocking their writers, and rejects new ones.
+func (m *Mixer) Close() {
+	m.mu.Lock()
+	m.closed = true
+	inputs := m.inputs
+	m.inputs = nil
+	m.mu.Unlock()
+	for _, in := range inputs {
+		in.Close()
+	}
+}
+
+// Mix fills out with the next len(out) samples of every input, summed and
+// peak-limited. Inputs that run short contribute silence for the remainder.
+// Returns false, leaving out untouched, when no input had any audio queued.
+func (m *Mixer) Mix(out []int16) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	foreground, active := false, false
+	for _, in := range m.inputs {
+		if len(in.queue) > 0 {
+			active = true
+			if in.role == Foreground {
+				foreground = true
+			}
+		}
+	}
+	if !active {
+		return false
+	}
+	if foreground {
+		m.holdLeft = m.holdTotal
+	}
+
+	for i := range out {
+		target := 1.0
+		if m.holdLeft > 0 {
+			target = m.duckGain
+			m.holdLeft--
+		}
+		if target < m.duck {
+			m.duck += (target - m.duck) * m.attack
+		} else {
+			m.duck += (target - m.duck) * m.release
+		}
+
+		var sum float64
+		for _, in := range m.inputs {
+			if i >= len(in.queue) {
+				continue
+			}
+			v := float64(in.queue[i])
+			if in.role == Background {
+				v *= m.duck
+			}
+			sum += v
+		}
+		out[i] = clampInt16(float32(m.limiter.process(sum)))
+	}
+
+	for _, in := range m.inputs {
+		in.consume(len(out))
+	}
+	return true
+}
+
+func (m *Mixer) remove(in *MixerInput) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, x := range m.inputs {
+		if x == in {
+			m.inputs = append(m.inputs[:i], m.inputs[i+1:]...)
+			return
+		}
+	}
+}
+
+// MixerInput is one producer's queue in a Mixer. A single goroutine should
+// write to it; Reset and Close may be called from any goroutine.
+type MixerInput struct {
+	m     *Mixer
+	role  MixerRole
+	queue []int16 // guarded by m.mu
+	space chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// Write queues samples for playback, blocking while the input is full. It
+// returns ctx.Err() if ctx ends first, or nil without queuing the rest once the
+// input is closed.
+func (in *MixerInput) Write(ctx context.Context, samples []int16) error {
+	capacity := OpusSampleRate * mixerQueueMs / 1000
+	for len(samples) > 0 {
+		select {
+		case <-in.done:
+			return nil
+		default:
+		}
+
+		in.m.mu.Lock()
+		n := min(len(samples), capacity-len(in.queue))
+		if n > 0 {
+			wasIdle := len(in.queue) == 0
+			in.queue = append(in.queue, samples[:n]...)
+			samples = samples[n:]
+			if wasIdle {
+				select {
+				case in.m.ready <- struct{}{}:
+				default:
+				}
+			}
+		}
+		in.m.mu.Unlock()
+		if len(samples) == 0 {
+			return nil
+		}
+
+		select {
+		case <-in.space:
+		case <-in.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Drain blocks until everything written has been mixed, the input is closed,
+// or ctx ends.
+func (in *MixerInput) Drain(ctx context.Context) error {
+	for {
+		in.m.mu.Lock()
+		empty := len(in.queue) == 0
+		in.m.mu.Unlock()
+		if empty {
+			return nil
+		}
+		select {
+		case <-in.space:
+		case <-in.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reset discards queued audio, e.g. when playback is cancelled.
+func (in *MixerInput) Reset() {
+	in.m.mu.Lock()
+	in.queue = in.queue[:0]
+	in.m.mu.Unlock()
+	in.signal()
+}
+
+// Close discards queued audio and detaches the input from the mixer. Idempotent.
+func (in *MixerInput) Close() {
+	in.once.Do(func() {
+		in.m.remove(in)
+		close(in.done)
+	})
+}
+
+// consume drops the first n samples; called with m.mu held.
+func (in *MixerInput) consume(n int) {
+	if len(in.queue) == 0 {
+		return
+	}
+	n = min(n, len(in.queue))
+	in.queue = append(in.queue[:0], in.queue[n:]...)
+	in.signal()
+}
+
+func (in *MixerInput) signal() {
+	select {
+	case in.space <- struct{}{}:
+	default:
+	}
+}
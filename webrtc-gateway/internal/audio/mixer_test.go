@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func constant(v int16, n int) []int16 {
+	out := make([]int16, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func TestMixerDucksBackground(t *testing.T) {
+	m := NewMixer(-20)
+	bg := m.AddInput(Background)
+	ctx := context.Background()
+	frame := make([]int16, SamplesPerFrame)
+
+	bg.Write(ctx, constant(1000, SamplesPerFrame))
+	m.Mix(frame)
+	if frame[SamplesPerFrame-1] != 1000 {
+		t.Fatalf("background alone: %d, want 1000", frame[SamplesPerFrame-1])
+	}
+
+	// Under foreground audio the background settles at -20 dB (100).
+	fg := m.AddInput(Foreground)
+	for i := 0; i < 10; i++ {
+		bg.Write(ctx, constant(1000, SamplesPerFrame))
+		fg.Write(ctx, constant(0, SamplesPerFrame))
+		m.Mix(frame)
+	}
+	if got := frame[SamplesPerFrame-1]; got < 95 || got > 110 {
+		t.Errorf("ducked background: %d, want about 100", got)
+	}
+
+	// After the hold and release it comes back to full level.
+	fg.Close()
+	for i := 0; i < 150; i++ {
+		bg.Write(ctx, constant(1000, SamplesPerFrame))
+		m.Mix(frame)
+	}
+	if got := frame[SamplesPerFrame-1]; got < 990 {
+		t.Errorf("released background: %d, want about 1000", got)
+	}
+}
+
+func TestMixerIdleAndPadding(t *testing.T) {
+	m := NewMixer(-15)
+	frame := make([]int16, SamplesPerFrame)
+	if m.Mix(frame) {
+		t.Fatal("Mix reported audio with no inputs")
+	}
+
+	in := m.AddInput(Foreground)
+	in.Write(context.Background(), constant(500, SamplesPerFrame/2))
+	if !m.Mix(frame) {
+		t.Fatal("Mix reported no audio with a queued input")
+	}
+	if frame[0] != 500 || frame[SamplesPerFrame-1] != 0 {
+		t.Errorf("short input: got %d..%d, want 500..0", frame[0], frame[SamplesPerFrame-1])
+	}
+	if m.Mix(frame) {
+		t.Error("Mix reported audio after the input ran out")
+	}
+}
+
+func TestMixerWriteBlocksUntilMixed(t *testing.T) {
+	m := NewMixer(-15)
+	in := m.AddInput(Foreground)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// More than the queue holds: Write must wait for the reader.
+	if err := in.Write(ctx, make([]int16, OpusSampleRate)); err != context.DeadlineExceeded {
+		t.Fatalf("Write over capacity returned %v, want deadline exceeded", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- in.Drain(context.Background()) }()
+	in.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Drain after Close returned %v", err)
+	}
+}
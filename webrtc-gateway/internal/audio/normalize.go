@@ -0,0 +1,132 @@
+package audio
+
+import "math"
+
+// LoudnessTarget is the level a LoudnessNormalizer steers playback toward.
+type LoudnessTarget struct {
+	TargetLUFS float64
+	// MaxGainDB caps the boost applied to quiet voices (attenuation is uncapped).
+	MaxGainDB float64
+}
+
+const (
+	// normalizerSubBlockMs is the measurement granularity; four sub-blocks make
+	// one 400ms BS.1770 gating block.
+	normalizerSubBlockMs = 100
+	// normalizerSmoothingMs is the time constant of gain changes, short enough
+	// to settle within the first words but slow enough not to pump.
+	normalizerSmoothingMs = 150.0
+)
+
+// LoudnessNormalizer levels a mono int16 stream toward a loudness target as it
+// plays. The gain follows the gated integrated loudness of everything seen so
+// far (estimated from partial blocks at the start) and is smoothed; a peak
+// limiter catches overshoot, including from an extra user volume.
+// Not thread-safe — use one per stream.
+type LoudnessNormalizer struct {
+	target     *LoudnessTarget // nil: volume and limiting only
+	sampleRate int
+
+	shelf, hp *biquad
+	subLen    int       // samples per sub-block
+	subSum    float64   // weighted energy of the current sub-block
+	subCount  int       // samples in the current sub-block
+	recent    []float64 // mean square of the last (up to) four sub-blocks
+	blocks    []float64 // mean square of every block so far
+
+	desired float64 // gain the integrated loudness so far calls for
+	gain    float64 // current smoothed linear gain
+	smooth  float64 // per-sample smoothing coefficient
+	limiter *limiter
+}
+
+// NewLoudnessNormalizer returns a normalizer for audio at sampleRate. A nil
+// target disables normalization, leaving volume and limiting.
+func NewLoudnessNormalizer(sampleRate int, target *LoudnessTarget) *LoudnessNormalizer {
+	shelf, hp := kWeighting(sampleRate)
+	return &LoudnessNormalizer{
+		target:     target,
+		sampleRate: sampleRate,
+		shelf:      shelf,
+		hp:         hp,
+		subLen:     sampleRate * normalizerSubBlockMs / 1000,
+		desired:    1,
+		gain:       1,
+		smooth:     1 - math.Exp(-1/(normalizerSmoothingMs/1000*float64(sampleRate))),
+		limiter:    newLimiter(sampleRate),
+	}
+}
+
+// GainDB returns the normalization gain currently applied (excluding volume).
+func (n *LoudnessNormalizer) GainDB() float64 {
+	return 20 * math.Log10(n.gain)
+}
+
+// Process levels samples in place and multiplies them by volume (1 = unchanged).
+func (n *LoudnessNormalizer) Process(samples []int16, volume float64) {
+	for i, s := range samples {
+		v := float64(s)
+		if n.target != nil {
+			n.measure(v)
+			n.gain += (n.desired - n.gain) * n.smooth
+		}
+		samples[i] = clampInt16(float32(n.limiter.process(v * n.gain * volume)))
+	}
+}
+
+// measure feeds one sample into the K-weighted loudness estimate.
+func (n *LoudnessNormalizer) measure(v float64) {
+	w := n.hp.step(n.shelf.step(v / math.MaxInt16))
+	n.subSum += w * w
+	n.subCount++
+	if n.subCount < n.subLen {
+		return
+	}
+
+	n.recent = append(n.recent, n.subSum/float64(n.subCount))
+	if len(n.recent) > 4 {
+		n.recent = n.recent[1:]
+	}
+	n.subSum, n.subCount = 0, 0
+
+	var sum float64
+	for _, p := range n.recent {
+		sum += p
+	}
+	n.blocks = append(n.blocks, sum/float64(len(n.recent)))
+
+	// Keep the previous gain while nothing above the gate has played.
+	if lufs, ok := gatedLoudness(n.blocks); ok {
+		n.desired = math.Pow(10, min(n.target.TargetLUFS-lufs, n.target.MaxGainDB)/20)
+	}
+}
+
+// gatedLoudness applies the BS.1770 absolute (-70 LUFS) and relative (-10 LU)
+// gates to block mean squares and returns the integrated loudness.
+func gatedLoudness(powers []float64) (float64, bool) {
+	loudness := func(p float64) float64 { return -0.691 + 10*math.Log10(p) }
+	gatedMean := func(threshold float64) (float64, bool) {
+		var sum float64
+		var count int
+		for _, p := range powers {
+			if p > 0 && loudness(p) > threshold {
+				sum += p
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, false
+		}
+		return sum / float64(count), true
+	}
+
+	abs, ok := gatedMean(-70)
+	if !ok {
+		return 0, false
+	}
+	rel, ok := gatedMean(loudness(abs) - 10)
+	if !ok {
+		return loudness(abs), true
+	}
+	return loudness(rel), true
+}
@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func lufsOf(samples []int16, rate int) float64 {
+	x := make([]float64, len(samples))
+	for i, v := range samples {
+		x[i] = float64(v)
+	}
+	l, _ := LoudnessLUFS(x, rate)
+	return l
+}
+
+func TestLoudnessNormalizerConverges(t *testing.T) {
+	target := &LoudnessTarget{TargetLUFS: -18, MaxGainDB: 20}
+	for _, amp := range []float64{1500, 20000} {
+		n := NewLoudnessNormalizer(OpusSampleRate, target)
+		samples := sine(440, OpusSampleRate, 3*OpusSampleRate, amp)
+		for i := 0; i < len(samples); i += SamplesPerFrame {
+			n.Process(samples[i:i+SamplesPerFrame], 1)
+		}
+		// Judge the last second, after the gain has settled.
+		if got := lufsOf(samples[2*OpusSampleRate:], OpusSampleRate); math.Abs(got-target.TargetLUFS) > 1 {
+			t.Errorf("amplitude %.0f: settled at %.1f LUFS, want about %.1f", amp, got, target.TargetLUFS)
+		}
+	}
+}
+
+func TestLoudnessNormalizerVolumeWithoutTarget(t *testing.T) {
+	n := NewLoudnessNormalizer(OpusSampleRate, nil)
+	samples := sine(440, OpusSampleRate, OpusSampleRate/2, 4000)
+	want := lufsOf(samples, OpusSampleRate) + 20*math.Log10(0.5)
+	n.Process(samples, 0.5)
+	if got := lufsOf(samples, OpusSampleRate); math.Abs(got-want) > 0.1 {
+		t.Errorf("volume 0.5: %.2f LUFS, want %.2f", got, want)
+	}
+	if n.GainDB() != 0 {
+		t.Errorf("normalization gain %.2f dB with no target", n.GainDB())
+	}
+}
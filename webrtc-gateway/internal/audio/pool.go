@@ -3,6 +3,7 @@ package audio
 import "sync"
 
 // InboundFrameBuffers holds pre-allocated buffers for the RTP decode→downsample→bytes pipeline.
+// DownsampleBuf fits Resampler(48k→16k).MaxOutput(MaxFrameSize).
 // Used via sync.Pool to avoid per-packet allocations in the hot path.
 type InboundFrameBuffers struct {
 	DecodeBuf     []int16 // cap: MaxFrameSize (5760)
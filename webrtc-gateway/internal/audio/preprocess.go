@@ -0,0 +1,222 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// PreprocessOptions controls the conditioning applied to audio before ASR.
+type PreprocessOptions struct {
+	// Normalize applies gain to bring integrated loudness to TargetLUFS.
+	Normalize  bool
+	TargetLUFS float64
+	// MaxGainDB caps the boost applied to quiet audio (attenuation is uncapped).
+	MaxGainDB float64
+	// HighPass removes DC offset and low-frequency rumble below ~80 Hz.
+	HighPass bool
+}
+
+// DefaultPreprocessOptions suit speech recognition on mixed mic and ingest audio.
+var DefaultPreprocessOptions = PreprocessOptions{
+	Normalize:  true,
+	TargetLUFS: -23,
+	MaxGainDB:  20,
+	HighPass:   true,
+}
+
+// PreprocessResult describes what Preprocess measured and applied.
+type PreprocessResult struct {
+	// LoudnessLUFS is the integrated loudness after high-pass filtering and
+	// before gain; valid only when Measured is true (false for silence).
+	LoudnessLUFS float64
+	Measured     bool
+	GainDB       float64
+	// LimitedSamples counts samples the limiter had to pull below full scale.
+	LimitedSamples int
+}
+
+const (
+	highPassHz = 80.0
+	// limiterCeiling is the peak level the limiter holds (-1 dBFS).
+	limiterCeiling = 0.891 * math.MaxInt16
+	// limiterReleaseMs is how quickly limiter gain reduction recovers.
+	limiterReleaseMs = 50.0
+)
+
+// Preprocess conditions 16-bit mono PCM (s16le) at sampleRate in place: optional
+// high-pass, then loudness normalization with a peak limiter.
+func Preprocess(pcm []byte, sampleRate int, opts PreprocessOptions) PreprocessResult {
+	var res PreprocessResult
+	n := len(pcm) / 2
+	if n == 0 {
+		return res
+	}
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = float64(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+	}
+
+	if opts.HighPass {
+		newHighPass(highPassHz, sampleRate).process(x)
+	}
+
+	res.LoudnessLUFS, res.Measured = LoudnessLUFS(x, sampleRate)
+	if opts.Normalize && res.Measured {
+		res.GainDB = min(opts.TargetLUFS-res.LoudnessLUFS, opts.MaxGainDB)
+	}
+	if res.GainDB != 0 {
+		res.LimitedSamples = applyGainLimited(x, math.Pow(10, res.GainDB/20), sampleRate)
+	}
+
+	for i, v := range x {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(clampInt16(float32(v))))
+	}
+	return res
+}
+
+// RMSDBFS returns the RMS level of 16-bit PCM relative to full scale, or -Inf for silence.
+func RMSDBFS(samples []int16) float64 {
+	if len(samples) == 0 {
+		return math.Inf(-1)
+	}
+	var sumSq float64
+	for _, s := range samples {
+		sumSq += float64(s) * float64(s)
+	}
+	return 20 * math.Log10(math.Sqrt(sumSq/float64(len(samples)))/math.MaxInt16)
+}
+
+// LoudnessLUFS returns the gated integrated loudness (ITU-R BS.1770-4) of mono
+// samples in 16-bit scale. ok is false when every block falls below the
+// absolute gate (silence).
+func LoudnessLUFS(x []float64, sampleRate int) (lufs float64, ok bool) {
+	if len(x) == 0 {
+		return 0, false
+	}
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = v / math.MaxInt16
+	}
+	shelf, hp := kWeighting(sampleRate)
+	shelf.process(y)
+	hp.process(y)
+
+	// Mean square of 400ms blocks with 75% overlap (one block if shorter).
+	block := sampleRate * 400 / 1000
+	step := block / 4
+	if len(y) < block {
+		block, step = len(y), len(y)
+	}
+	var powers []float64
+	for start := 0; start+block <= len(y); start += step {
+		var sum float64
+		for _, v := range y[start : start+block] {
+			sum += v * v
+		}
+		powers = append(powers, sum/float64(block))
+	}
+
+	return gatedLoudness(powers)
+}
+
+// applyGainLimited multiplies x by gain, holding peaks under limiterCeiling.
+// Returns the number of samples that needed gain reduction.
+func applyGainLimited(x []float64, gain float64, sampleRate int) int {
+	l := newLimiter(sampleRate)
+	limited := 0
+	for i, v := range x {
+		x[i] = l.process(v * gain)
+		if l.reduction < 1 {
+			limited++
+		}
+	}
+	return limited
+}
+
+// limiter is an instant-attack, exponential-release peak limiter holding
+// samples (in 16-bit scale) under limiterCeiling.
+type limiter struct {
+	release   float64
+	reduction float64 // current gain reduction, 1 = none
+}
+
+func newLimiter(sampleRate int) *limiter {
+	return &limiter{
+		release:   math.Exp(-1 / (limiterReleaseMs / 1000 * float64(sampleRate))),
+		reduction: 1,
+	}
+}
+
+func (l *limiter) process(y float64) float64 {
+	// Recover toward unity, then clamp down instantly if this peak needs it.
+	l.reduction = 1 - (1-l.reduction)*l.release
+	if a := math.Abs(y); a*l.reduction > limiterCeiling {
+		l.reduction = limiterCeiling / a
+	}
+	return y * l.reduction
+}
+
+// biquad is a direct-form I second-order IIR filter with normalized a0.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) step(v float64) float64 {
+	y := f.b0*v + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, v
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+func (f *biquad) process(x []float64) {
+	for i, v := range x {
+		x[i] = f.step(v)
+	}
+}
+
+// newHighPass returns a 2nd-order Butterworth high-pass (RBJ cookbook).
+func newHighPass(cutoff float64, sampleRate int) *biquad {
+	w := 2 * math.Pi * cutoff / float64(sampleRate)
+	alpha := math.Sin(w) / math.Sqrt2 // sin(w) / 2Q with Q = 1/√2
+	cw := math.Cos(w)
+	a0 := 1 + alpha
+	return &biquad{
+		b0: (1 + cw) / 2 / a0,
+		b1: -(1 + cw) / a0,
+		b2: (1 + cw) / 2 / a0,
+		a1: -2 * cw / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// kWeighting returns the BS.1770 pre-filter (high shelf) and RLB high-pass,
+// derived for any sample rate as in libebur128.
+func kWeighting(sampleRate int) (shelf, hp *biquad) {
+	fs := float64(sampleRate)
+
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = &biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	hp = &biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, hp
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func sineBytes(freq float64, n int, amp float64, dc int16) []byte {
+	s := sine(freq, PCMSampleRate, n, amp)
+	for i := range s {
+		s[i] += dc
+	}
+	return Int16ToBytes(s)
+}
+
+func TestLoudnessLUFSFullScaleSine(t *testing.T) {
+	// BS.1770: a full-scale 1kHz sine reads -3.01 LUFS.
+	s := sine(1000, 48000, 48000, math.MaxInt16)
+	x := make([]float64, len(s))
+	for i, v := range s {
+		x[i] = float64(v)
+	}
+	lufs, ok := LoudnessLUFS(x, 48000)
+	if !ok || math.Abs(lufs+3.01) > 0.1 {
+		t.Errorf("got %.2f LUFS (ok=%v), want -3.01", lufs, ok)
+	}
+}
+
+func TestPreprocessNormalizesQuietAudio(t *testing.T) {
+	pcm := sineBytes(440, PCMSampleRate*2, 600, 0) // about -38 LUFS
+	res := Preprocess(pcm, PCMSampleRate, DefaultPreprocessOptions)
+	if !res.Measured || res.GainDB <= 0 {
+		t.Fatalf("expected a boost, got %+v", res)
+	}
+
+	out := BytesToInt16(pcm)
+	x := make([]float64, len(out))
+	for i, v := range out {
+		x[i] = float64(v)
+	}
+	lufs, _ := LoudnessLUFS(x, PCMSampleRate)
+	if math.Abs(lufs-DefaultPreprocessOptions.TargetLUFS) > 0.5 {
+		t.Errorf("normalized to %.2f LUFS, want %.0f", lufs, DefaultPreprocessOptions.TargetLUFS)
+	}
+}
+
+func TestPreprocessCapsGain(t *testing.T) {
+	pcm := sineBytes(440, PCMSampleRate, 50, 0) // about -59 LUFS
+	res := Preprocess(pcm, PCMSampleRate, DefaultPreprocessOptions)
+	if res.GainDB != DefaultPreprocessOptions.MaxGainDB {
+		t.Errorf("gain %.2f dB, want capped at %.0f", res.GainDB, DefaultPreprocessOptions.MaxGainDB)
+	}
+}
+
+func TestPreprocessLimitsPeaks(t *testing.T) {
+	// Quiet tone with short loud clicks that barely move the loudness: the boost
+	// it gets would clip the clicks without the limiter.
+	s := sine(440, PCMSampleRate, PCMSampleRate*2, 500)
+	for i := 0; i < len(s); i += PCMSampleRate / 10 {
+		s[i] = 20000
+	}
+	pcm := Int16ToBytes(s)
+	res := Preprocess(pcm, PCMSampleRate, PreprocessOptions{Normalize: true, TargetLUFS: -23, MaxGainDB: 20})
+	if res.LimitedSamples == 0 {
+		t.Fatalf("expected the limiter to engage, got %+v", res)
+	}
+	for i, s := range BytesToInt16(pcm) {
+		if math.Abs(float64(s)) > limiterCeiling+1 {
+			t.Fatalf("sample %d = %d exceeds the limiter ceiling", i, s)
+		}
+	}
+}
+
+func TestPreprocessRemovesDC(t *testing.T) {
+	pcm := sineBytes(1000, PCMSampleRate, 3000, 4000)
+	Preprocess(pcm, PCMSampleRate, PreprocessOptions{HighPass: true})
+
+	var sum float64
+	out := BytesToInt16(pcm)
+	for _, s := range out[len(out)/2:] {
+		sum += float64(s)
+	}
+	if mean := sum / float64(len(out)/2); math.Abs(mean) > 20 {
+		t.Errorf("DC offset %.1f remains after high-pass", mean)
+	}
+}
+
+func TestPreprocessSilence(t *testing.T) {
+	pcm := make([]byte, PCMSampleRate*2)
+	res := Preprocess(pcm, PCMSampleRate, DefaultPreprocessOptions)
+	if res.Measured || res.GainDB != 0 {
+		t.Errorf("silence: got %+v, want no measurement and no gain", res)
+	}
+}
@@ -1,50 +1,175 @@
 package audio
 
-import "encoding/binary"
-
-// Downsample48to16 converts 48kHz mono int16 samples to 16kHz
-// by averaging each group of 3 consecutive samples.
-func Downsample48to16(in []int16) []int16 {
-	out := make([]int16, len(in)/3)
-	for i := range out {
-		sum := int32(in[i*3]) + int32(in[i*3+1]) + int32(in[i*3+2])
-		out[i] = int16(sum / 3)
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// PCMSampleRate is the sample rate of the gateway's internal PCM
+// (ring buffer, ASR input, TTS output).
+const PCMSampleRate = 16000
+
+const (
+	// resamplerZeroCrossings is the number of sinc zero crossings on each side
+	// of the filter centre; more gives a sharper transition band.
+	resamplerZeroCrossings = 32
+	// resamplerRolloff places the passband edge as a fraction of the lower Nyquist
+	// frequency, leaving room for the transition band below it.
+	resamplerRolloff = 0.85
+)
+
+// polyphaseFilter is a windowed-sinc lowpass split into up phases.
+// Immutable once designed, so it is shared by all resamplers with the same ratio.
+type polyphaseFilter struct {
+	up, down int
+	taps     int       // taps per phase
+	coeffs   []float32 // phase-major; each phase stored reversed for a forward dot product
+}
+
+var filterCache sync.Map // [2]int{up, down} → *polyphaseFilter
+
+// Resampler converts mono int16 audio between arbitrary sample rates with a
+// polyphase windowed-sinc FIR filter (upsample by L, lowpass, downsample by M).
+// Filter state carries across calls so a stream can be processed frame by frame
+// without discontinuities. Not thread-safe — use one per stream.
+type Resampler struct {
+	f    *polyphaseFilter
+	hist []float32 // last taps-1 input samples, oldest first
+	buf  []float32 // scratch: hist followed by the current input
+	next int       // upsampled-domain position of the next output, relative to the current input
+}
+
+// NewResampler creates a resampler from inRate to outRate Hz.
+func NewResampler(inRate, outRate int) *Resampler {
+	g := gcd(inRate, outRate)
+	up, down := outRate/g, inRate/g
+
+	key := [2]int{up, down}
+	f, ok := filterCache.Load(key)
+	if !ok {
+		f, _ = filterCache.LoadOrStore(key, designFilter(up, down))
+	}
+	pf := f.(*polyphaseFilter)
+	return &Resampler{
+		f:    pf,
+		hist: make([]float32, pf.taps-1),
 	}
-	return out
 }
 
-// Downsample48to16Into writes 16kHz samples into dst, avoiding allocation.
-// dst must have capacity >= len(in)/3. Returns the used portion.
-func Downsample48to16Into(in []int16, dst []int16) []int16 {
-	n := len(in) / 3
-	for i := 0; i < n; i++ {
-		sum := int32(in[i*3]) + int32(in[i*3+1]) + int32(in[i*3+2])
-		dst[i] = int16(sum / 3)
+// designFilter builds a Blackman-windowed sinc lowpass at the upsampled rate,
+// cut off below the lower of the two Nyquist frequencies.
+func designFilter(up, down int) *polyphaseFilter {
+	// Cutoff in cycles per sample at the upsampled rate.
+	fc := resamplerRolloff * 0.5 / float64(max(up, down))
+	taps := int(math.Ceil(resamplerZeroCrossings / fc / float64(up)))
+	n := taps * up
+
+	proto := make([]float64, n)
+	center := float64(n-1) / 2
+	var sum float64
+	for i := range proto {
+		x := float64(i) - center
+		sinc := 2 * fc
+		if x != 0 {
+			sinc = math.Sin(2*math.Pi*fc*x) / (math.Pi * x)
+		}
+		phase := 2 * math.Pi * float64(i) / float64(n-1)
+		window := 0.42 - 0.5*math.Cos(phase) + 0.08*math.Cos(2*phase)
+		proto[i] = sinc * window
+		sum += proto[i]
 	}
-	return dst[:n]
+
+	// Scale for unity passband gain: zero-stuffing by up divides the signal by up.
+	scale := float64(up) / sum
+	coeffs := make([]float32, n)
+	for p := 0; p < up; p++ {
+		for j := 0; j < taps; j++ {
+			coeffs[p*taps+(taps-1-j)] = float32(proto[p+j*up] * scale)
+		}
+	}
+	return &polyphaseFilter{up: up, down: down, taps: taps, coeffs: coeffs}
 }
 
-// Upsample16to48 converts 16kHz mono int16 samples to 48kHz
-// by repeating each sample 3 times.
-func Upsample16to48(in []int16) []int16 {
-	out := make([]int16, len(in)*3)
-	for i, s := range in {
-		out[i*3] = s
-		out[i*3+1] = s
-		out[i*3+2] = s
+// MaxOutput returns the most samples ProcessInto can produce from n input samples.
+func (r *Resampler) MaxOutput(n int) int {
+	return (n*r.f.up + r.f.down - 1) / r.f.down
+}
+
+// Process resamples in, allocating the output.
+func (r *Resampler) Process(in []int16) []int16 {
+	return r.ProcessInto(in, make([]int16, 0, r.MaxOutput(len(in))))
+}
+
+// ProcessInto resamples in and writes the output into dst, avoiding allocation
+// when dst has capacity >= MaxOutput(len(in)). Returns the used portion.
+// The output is delayed by half the filter length.
+func (r *Resampler) ProcessInto(in []int16, dst []int16) []int16 {
+	f := r.f
+	h := len(r.hist)
+
+	r.buf = append(r.buf[:0], r.hist...)
+	for _, s := range in {
+		r.buf = append(r.buf, float32(s))
+	}
+
+	out := dst[:0]
+	for {
+		i := r.next / f.up
+		if i >= len(in) {
+			break
+		}
+		p := r.next % f.up
+		c := f.coeffs[p*f.taps : (p+1)*f.taps]
+		x := r.buf[i : i+f.taps] // input samples i-taps+1 .. i
+		x = x[:len(c)]
+
+		// Four accumulators break the add dependency chain.
+		var a0, a1, a2, a3 float32
+		k := 0
+		for ; k+4 <= len(c); k += 4 {
+			a0 += c[k] * x[k]
+			a1 += c[k+1] * x[k+1]
+			a2 += c[k+2] * x[k+2]
+			a3 += c[k+3] * x[k+3]
+		}
+		for ; k < len(c); k++ {
+			a0 += c[k] * x[k]
+		}
+		acc := (a0 + a1) + (a2 + a3)
+		out = append(out, clampInt16(acc))
+		r.next += f.down
 	}
+
+	r.next -= len(in) * f.up
+	copy(r.hist, r.buf[len(r.buf)-h:])
 	return out
 }
 
-// Upsample16to48Into writes 48kHz samples into dst, avoiding allocation.
-// dst must have capacity >= len(in)*3. Returns the used portion.
-func Upsample16to48Into(in []int16, dst []int16) []int16 {
-	for i, s := range in {
-		dst[i*3] = s
-		dst[i*3+1] = s
-		dst[i*3+2] = s
+// Reset clears the filter history, as if no audio had been processed.
+func (r *Resampler) Reset() {
+	clear(r.hist)
+	r.next = 0
+}
+
+func clampInt16(v float32) int16 {
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	}
+	if v < 0 {
+		return int16(v - 0.5)
+	}
+	return int16(v + 0.5)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
 	}
-	return dst[:len(in)*3]
+	return a
 }
 
 // Int16ToBytes converts int16 samples to s16le byte slice.
@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// sine generates n samples of a sine wave at freq Hz with the given peak amplitude.
+func sine(freq float64, rate, n int, amp float64) []int16 {
+	out := make([]int16, n)
+	for i := range out {
+		out[i] = int16(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+// toneGainDB measures the level of a pure tone after resampling, relative to
+// the input, skipping the filter's start-up transient.
+func toneGainDB(t *testing.T, inRate, outRate int, freq float64) float64 {
+	t.Helper()
+	const amp = 10000.0
+	in := sine(freq, inRate, inRate/2, amp) // 500ms
+	out := NewResampler(inRate, outRate).Process(in)
+
+	steady := out[len(out)/4:]
+	var sumSq float64
+	for _, s := range steady {
+		sumSq += float64(s) * float64(s)
+	}
+	rms := math.Sqrt(sumSq / float64(len(steady)))
+	return 20 * math.Log10(rms*math.Sqrt2/amp)
+}
+
+func TestResamplerPassband(t *testing.T) {
+	ratios := [][2]int{
+		{48000, 16000},
+		{16000, 48000},
+		{22050, 16000},
+		{24000, 16000},
+		{44100, 16000},
+		{16000, 44100},
+	}
+	// Sweep up to the passband edge of the 16kHz side.
+	for _, r := range ratios {
+		for _, freq := range []float64{100, 440, 1000, 3000, 6000} {
+			if gain := toneGainDB(t, r[0], r[1], freq); math.Abs(gain) > 0.5 {
+				t.Errorf("%d→%d at %.0fHz: gain %.2f dB, want within ±0.5 dB", r[0], r[1], freq, gain)
+			}
+		}
+	}
+}
+
+func TestResamplerStopband(t *testing.T) {
+	// Tones above the output Nyquist must be removed, not folded back.
+	tests := []struct {
+		in, out int
+		freq    float64
+	}{
+		{48000, 16000, 9000},
+		{48000, 16000, 12000},
+		{48000, 16000, 20000},
+		{22050, 16000, 10000},
+		{44100, 16000, 15000},
+	}
+	for _, tt := range tests {
+		if gain := toneGainDB(t, tt.in, tt.out, tt.freq); gain > -60 {
+			t.Errorf("%d→%d at %.0fHz: alias at %.1f dB, want below -60 dB", tt.in, tt.out, tt.freq, gain)
+		}
+	}
+}
+
+func TestResamplerImageRejection(t *testing.T) {
+	// Upsampling a 3kHz tone must not leave images at 16k±3kHz.
+	in := sine(3000, 16000, 8000, 10000)
+	out := NewResampler(16000, 48000).Process(in)
+	steady := out[len(out)/4:]
+
+	// Project onto the 13kHz image.
+	var re, im float64
+	for i, s := range steady {
+		ph := 2 * math.Pi * 13000 * float64(i) / 48000
+		re += float64(s) * math.Cos(ph)
+		im += float64(s) * math.Sin(ph)
+	}
+	level := 2 * math.Hypot(re, im) / float64(len(steady))
+	if db := 20 * math.Log10(level/10000); db > -60 {
+		t.Errorf("13kHz image at %.1f dB, want below -60 dB", db)
+	}
+}
+
+func TestResamplerStreamingMatchesOneShot(t *testing.T) {
+	in := sine(1000, 22050, 22050, 8000)
+	want := NewResampler(22050, 16000).Process(in)
+
+	r := NewResampler(22050, 16000)
+	var got []int16
+	dst := make([]int16, r.MaxOutput(441))
+	for off := 0; off < len(in); off += 441 {
+		got = append(got, r.ProcessInto(in[off:min(off+441, len(in))], dst)...)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("streaming produced %d samples, one-shot %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: streaming %d, one-shot %d", i, got[i], want[i])
+		}
+	}
+	if exp := len(in) * 16000 / 22050; len(got) < exp-1 || len(got) > exp+1 {
+		t.Errorf("expected ~%d output samples, got %d", exp, len(got))
+	}
+}
+
+func TestResamplerFrameSizes(t *testing.T) {
+	down := NewResampler(OpusSampleRate, PCMSampleRate)
+	up := NewResampler(PCMSampleRate, OpusSampleRate)
+	for i := 0; i < 10; i++ {
+		if n := len(down.Process(make([]int16, SamplesPerFrame))); n != SamplesPerFrame/3 {
+			t.Fatalf("48k→16k frame: got %d samples, want %d", n, SamplesPerFrame/3)
+		}
+		if n := len(up.Process(make([]int16, SamplesPerFrame/3))); n != SamplesPerFrame {
+			t.Fatalf("16k→48k frame: got %d samples, want %d", n, SamplesPerFrame)
+		}
+	}
+}
+
+func benchmarkResampler(b *testing.B, inRate, outRate, frame int) {
+	r := NewResampler(inRate, outRate)
+	in := sine(1000, inRate, frame, 8000)
+	dst := make([]int16, r.MaxOutput(frame))
+	b.ReportAllocs()
+	b.SetBytes(int64(frame * 2))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ProcessInto(in, dst)
+	}
+}
+
+func BenchmarkResampler48to16(b *testing.B)    { benchmarkResampler(b, 48000, 16000, 960) }
+func BenchmarkResampler16to48(b *testing.B)    { benchmarkResampler(b, 16000, 48000, 320) }
+func BenchmarkResampler22050to16(b *testing.B) { benchmarkResampler(b, 22050, 16000, 441) }
+func BenchmarkResampler44100to16(b *testing.B) { benchmarkResampler(b, 44100, 16000, 882) }
@@ -0,0 +1,116 @@
+package audio
+
+import "math"
+
+// WSOLA parameters at 48kHz: 20ms frames overlapped by half, each placed
+// within ±5ms of its nominal position where it best continues the previous one.
+const (
+	stretchFrame  = 960
+	stretchHop    = stretchFrame / 2
+	stretchSearch = 240
+)
+
+// stretchWindow is a periodic Hann window; at 50% overlap its halves sum to 1.
+var stretchWindow = func() []float64 {
+	w := make([]float64, stretchFrame)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/stretchFrame)
+	}
+	return w
+}()
+
+// SpeedChanger changes the tempo of a mono int16 stream at 48kHz without
+// changing its pitch, by waveform-similarity overlap-add (WSOLA): frames are
+// read from the input at rate × the pace they are written to the output.
+// State is carried across calls, allowing the rate to change between chunks.
+// Output lags input by about one frame; Flush returns the rest at the end.
+// Not thread-safe — use one per stream.
+type SpeedChanger struct {
+	buf    []float64 // input not yet consumed
+	pos    float64   // nominal start of the next frame in buf
+	last   int       // start in buf of the last frame used (may be < 0 once dropped)
+	framed bool      // a frame has been output
+	tail   []float64 // windowed second half of the last frame, not yet output
+}
+
+// Process returns in played back at the given rate (2.0 = twice as fast).
+// Output length is approximately len(in)/rate.
+func (c *SpeedChanger) Process(in []int16, rate float64) []int16 {
+	if rate <= 0 {
+		return nil
+	}
+	if c.tail == nil {
+		c.tail = make([]float64, stretchHop)
+	}
+	for _, s := range in {
+		c.buf = append(c.buf, float64(s))
+	}
+
+	var out []int16
+	for int(c.pos)+stretchSearch+stretchFrame <= len(c.buf) {
+		start := c.bestStart(int(c.pos))
+		frame := c.buf[start : start+stretchFrame]
+		for i := 0; i < stretchHop; i++ {
+			out = append(out, clampInt16(float32(c.tail[i]+frame[i]*stretchWindow[i])))
+			c.tail[i] = frame[stretchHop+i] * stretchWindow[stretchHop+i]
+		}
+		c.last, c.framed = start, true
+		c.pos += stretchHop * rate
+	}
+
+	// Drop input no later frame can reach.
+	drop := int(c.pos) - stretchSearch
+	if c.framed {
+		drop = min(drop, c.last+stretchHop)
+	}
+	if drop > 0 {
+		c.buf = append(c.buf[:0], c.buf[drop:]...)
+		c.pos -= float64(drop)
+		c.last -= drop
+	}
+	return out
+}
+
+// bestStart returns the frame start within stretchSearch of nominal whose
+// first half best matches the natural continuation of the last frame.
+func (c *SpeedChanger) bestStart(nominal int) int {
+	if !c.framed {
+		return nominal
+	}
+	natural := c.buf[c.last+stretchHop : c.last+stretchFrame]
+	best, bestScore := nominal, math.Inf(-1)
+	for start := max(0, nominal-stretchSearch); start <= nominal+stretchSearch; start++ {
+		var score float64
+		for i := 0; i < stretchHop; i += 2 {
+			score += natural[i] * c.buf[start+i]
+		}
+		if score > bestScore {
+			best, bestScore = start, score
+		}
+	}
+	return best
+}
+
+// Flush returns the audio still held back and resets the changer. The last
+// frame is completed from the input that follows it, unstretched.
+func (c *SpeedChanger) Flush() []int16 {
+	if c.tail == nil {
+		return nil // nothing processed
+	}
+	var out []int16
+	if !c.framed {
+		for _, s := range c.buf[min(int(c.pos), len(c.buf)):] {
+			out = append(out, clampInt16(float32(s)))
+		}
+	} else {
+		rest := c.buf[c.last+stretchHop:]
+		for i, s := range rest {
+			if i < stretchHop {
+				s = c.tail[i] + s*stretchWindow[i]
+			}
+			out = append(out, clampInt16(float32(s)))
+		}
+	}
+	*c = SpeedChanger{}
+	return out
+}
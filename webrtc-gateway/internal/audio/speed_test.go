@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// zeroCrossingHz estimates the frequency of a tone from its sign changes.
+func zeroCrossingHz(s []int16, rate int) float64 {
+	n := 0
+	for i := 1; i < len(s); i++ {
+		if (s[i-1] < 0) != (s[i] < 0) {
+			n++
+		}
+	}
+	return float64(n) / 2 / (float64(len(s)) / float64(rate))
+}
+
+func TestSpeedChangerKeepsPitch(t *testing.T) {
+	in := sine(440, OpusSampleRate, OpusSampleRate, 10000) // 1s
+	for _, rate := range []float64{0.5, 0.8, 1.0, 1.5, 2.0} {
+		var c SpeedChanger
+		var out []int16
+		// Chunks of 20ms, as played.
+		for i := 0; i < len(in); i += 960 {
+			out = append(out, c.Process(in[i:i+960], rate)...)
+		}
+		out = append(out, c.Flush()...)
+
+		wantLen := float64(len(in)) / rate
+		if got := float64(len(out)); math.Abs(got-wantLen) > 0.05*wantLen {
+			t.Errorf("rate %.1f: %d samples, want ~%.0f", rate, len(out), wantLen)
+		}
+		if hz := zeroCrossingHz(out[960:len(out)-960], OpusSampleRate); math.Abs(hz-440) > 10 {
+			t.Errorf("rate %.1f: tone at %.0f Hz, want 440 Hz", rate, hz)
+		}
+	}
+}
+
+func TestSpeedChangerRateChange(t *testing.T) {
+	in := sine(440, OpusSampleRate, OpusSampleRate, 10000)
+	var c SpeedChanger
+	var out []int16
+	half := len(in) / 2
+	for i := 0; i < half; i += 960 {
+		out = append(out, c.Process(in[i:i+960], 1.0)...)
+	}
+	for i := half; i < len(in); i += 960 {
+		out = append(out, c.Process(in[i:i+960], 2.0)...)
+	}
+	out = append(out, c.Flush()...)
+
+	// 0.5s at 1x and 0.5s at 2x.
+	want := 0.75 * float64(len(in))
+	if got := float64(len(out)); math.Abs(got-want) > 0.05*want {
+		t.Errorf("%d samples, want ~%.0f", len(out), want)
+	}
+	if c.Flush() != nil {
+		t.Error("Flush after Flush returned audio")
+	}
+}
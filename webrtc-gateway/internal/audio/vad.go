@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	// VADFrameSamples is the analysis frame size: 20ms at 16kHz.
+	VADFrameSamples = 320
+
+	vadMinSpeechRMS   = 200.0 // absolute floor, ~-44 dBFS; quieter is never speech
+	vadLoudRatio      = 4.0   // ~+12 dB over the noise floor: speech regardless of ZCR
+	vadSoftRatio      = 2.0   // ~+6 dB over the noise floor: speech if voiced (low ZCR)
+	vadMaxVoicedZCR   = 0.25  // zero crossings per sample; broadband noise sits higher
+	vadHangoverFrames = 15    // 300ms: bridges short pauses between words
+	vadFloorAttack    = 0.002 // slow rise of the noise floor while loud
+	vadFloorRelease   = 0.1   // faster fall of the noise floor in quiet frames
+	vadInitialFloor   = 100.0
+)
+
+// VAD is an energy + zero-crossing-rate voice activity detector for
+// 16kHz mono s16le PCM. It tracks an adaptive noise floor so that steady
+// background noise is not classified as speech, and holds the speech decision
+// for a short hangover so pauses between words don't split an utterance.
+// Not thread-safe — use one per stream.
+type VAD struct {
+	noiseFloor float64
+	hangover   int
+}
+
+// NewVAD creates a voice activity detector with an initial noise floor estimate.
+func NewVAD() *VAD {
+	return &VAD{noiseFloor: vadInitialFloor}
+}
+
+// IsSpeech classifies one frame of s16le PCM (ideally VADFrameSamples samples)
+// and updates the detector state.
+func (v *VAD) IsSpeech(frame []byte) bool {
+	n := len(frame) / 2
+	if n == 0 {
+		return v.hangover > 0
+	}
+
+	var sumSq float64
+	crossings := 0
+	prev := int16(binary.LittleEndian.Uint16(frame))
+	for i := 0; i < n; i++ {
+		s := int16(binary.LittleEndian.Uint16(frame[i*2:]))
+		sumSq += float64(s) * float64(s)
+		if (s >= 0) != (prev >= 0) {
+			crossings++
+		}
+		prev = s
+	}
+	rms := math.Sqrt(sumSq / float64(n))
+	zcr := float64(crossings) / float64(n)
+
+	loud := rms > vadMinSpeechRMS && rms > v.noiseFloor*vadLoudRatio
+	voiced := rms > vadMinSpeechRMS && rms > v.noiseFloor*vadSoftRatio && zcr < vadMaxVoicedZCR
+	speech := loud || voiced
+
+	// Track the noise floor: follow quiet frames quickly, creep up slowly
+	// otherwise so a permanently louder background is eventually learned.
+	if !speech && rms < v.noiseFloor*vadSoftRatio {
+		v.noiseFloor += (rms - v.noiseFloor) * vadFloorRelease
+	} else {
+		v.noiseFloor += (rms - v.noiseFloor) * vadFloorAttack
+	}
+	if v.noiseFloor < 1 {
+		v.noiseFloor = 1
+	}
+
+	if speech {
+		v.hangover = vadHangoverFrames
+		return true
+	}
+	if v.hangover > 0 {
+		v.hangover--
+		return true
+	}
+	return false
+}
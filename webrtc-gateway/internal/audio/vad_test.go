@@ -0,0 +1,99 @@
+package audio
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// noiseFrame returns a frame of uniform white noise with the given RMS.
+func noiseFrame(rng *rand.Rand, rms float64) []byte {
+	s := make([]int16, VADFrameSamples)
+	for i := range s {
+		s[i] = int16((rng.Float64()*2 - 1) * rms * 1.732) // uniform: peak = √3·rms
+	}
+	return Int16ToBytes(s)
+}
+
+// toneFrame returns a frame of a 200Hz tone, voiced-speech-like in its zero
+// crossings, with the given RMS.
+func toneFrame(rms float64) []byte {
+	return Int16ToBytes(sine(200, PCMSampleRate, VADFrameSamples, rms*1.414))
+}
+
+func TestVADClassifies(t *testing.T) {
+	silence := make([]byte, VADFrameSamples*2)
+	rng := rand.New(rand.NewSource(1))
+	for _, tt := range []struct {
+		name  string
+		frame func() []byte
+		want  bool
+	}{
+		{"silence", func() []byte { return silence }, false},
+		{"loud tone", func() []byte { return toneFrame(3000) }, true},
+		// 3× the initial floor: speech if voiced, not if broadband.
+		{"soft tone", func() []byte { return toneFrame(300) }, true},
+		{"soft noise", func() []byte { return noiseFrame(rng, 300) }, false},
+		// Below the absolute floor even over a silent background.
+		{"faint tone", func() []byte { return toneFrame(150) }, false},
+	} {
+		v := NewVAD()
+		for i := 0; i < 50; i++ {
+			if got := v.IsSpeech(tt.frame()); got != tt.want {
+				t.Errorf("%s: frame %d speech = %v, want %v", tt.name, i, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestVADLearnsNoiseFloor(t *testing.T) {
+	// A hum loud enough to start out as speech is learned as background noise.
+	v := NewVAD()
+	rng := rand.New(rand.NewSource(1))
+	if !v.IsSpeech(noiseFrame(rng, 1000)) {
+		t.Fatal("sudden loud noise not detected")
+	}
+	for i := 0; i < 2000; i++ {
+		v.IsSpeech(noiseFrame(rng, 1000))
+	}
+	for i := 0; i < 10; i++ {
+		if v.IsSpeech(noiseFrame(rng, 1000)) {
+			t.Fatalf("steady noise still speech after %d frames", 2000+i)
+		}
+	}
+	// Speech well over that background is still detected.
+	if !v.IsSpeech(toneFrame(8000)) {
+		t.Error("speech over learned noise not detected")
+	}
+}
+
+func TestVADOnsetAndHangover(t *testing.T) {
+	silence := make([]byte, VADFrameSamples*2)
+	v := NewVAD()
+	for i := 0; i < 10; i++ {
+		if v.IsSpeech(silence) {
+			t.Fatal("silence classified as speech")
+		}
+	}
+	// Onset on the first speech frame.
+	if !v.IsSpeech(toneFrame(3000)) {
+		t.Fatal("no onset on the first speech frame")
+	}
+	// Held through a pause shorter than the hangover, then released.
+	for i := 0; i < vadHangoverFrames; i++ {
+		if !v.IsSpeech(silence) {
+			t.Fatalf("released after %d silent frames, want %d", i, vadHangoverFrames)
+		}
+	}
+	if v.IsSpeech(silence) {
+		t.Error("still speech after the hangover")
+	}
+	// The hangover restarts with each speech frame.
+	v.IsSpeech(toneFrame(3000))
+	for i := 0; i < vadHangoverFrames-1; i++ {
+		v.IsSpeech(silence)
+	}
+	if !v.IsSpeech(toneFrame(3000)) || !v.IsSpeech(silence) {
+		t.Error("hangover not restarted by speech")
+	}
+}
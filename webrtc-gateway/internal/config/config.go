@@ -21,22 +21,83 @@ type Config struct {
 	ActionTimeoutSec        int
 	MaxInferenceConcurrency int
 	MaxIngestDurationSec    int
+
+	// Relayed ingest audio is attenuated by this much (dB) under TTS
+	IngestDuckDB int
+
+	// Ingest reconnect policy for network sources (overridable per ingest)
+	IngestReconnectMaxAttempts      int
+	IngestReconnectInitialBackoffMs int
+	IngestReconnectMaxBackoffMs     int
+	IngestReconnectJitterPct        int
+
+	// Optional ingest sources: a server-side media library (file:) and an
+	// S3-compatible object store (s3://bucket/key). Disabled when unset.
+	MediaLibraryDir   string
+	S3Endpoint        string
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
+	// Live captioning
+	CaptionWindowSec int
+
+	// ASR preprocessing defaults (overridable per session)
+	ASRNormalize  bool
+	ASRTargetLUFS int
+	ASRMaxGainDB  int
+	ASRHighPass   bool
+
+	// TTS playback loudness normalization
+	TTSNormalize  bool
+	TTSTargetLUFS int
+	TTSMaxGainDB  int
+
+	// Inbound jitter buffer: upper bound on how long a gap is held open (ms)
+	JitterMaxDelayMs int
+
+	// Disk-backed DVR tier (disabled when DVRDir is empty)
+	DVRDir        string
+	DVRSec        int
+	DVRSegmentSec int
 }
 
 func Load() *Config {
 	return &Config{
-		ListenAddr:              getEnv("LISTEN_ADDR", ":9090"),
-		InternalAPIAddr:         getEnv("INTERNAL_API_ADDR", ":9091"),
-		MetricsAddr:             getEnv("METRICS_ADDR", ":9092"),
-		ASRAddr:                 getEnv("ASR_ADDR", "localhost:50051"),
-		TTSAddr:                 getEnv("TTS_ADDR", "localhost:50052"),
-		RingBufferSec:           getEnvInt("RING_BUFFER_SEC", 60),
-		STUNServers:             getEnvList("STUN_SERVERS", []string{"stun:stun.l.google.com:19302"}),
-		MaxSessions:             getEnvInt("MAX_SESSIONS", 100),
-		MaxLookbackSec:          getEnvInt("MAX_LOOKBACK_SEC", 60),
-		ActionTimeoutSec:        getEnvInt("ACTION_TIMEOUT_SEC", 60),
-		MaxInferenceConcurrency: getEnvInt("MAX_INFERENCE_CONCURRENCY", 4),
-		MaxIngestDurationSec:    getEnvInt("MAX_INGEST_DURATION_SEC", 1800),
+		ListenAddr:                      getEnv("LISTEN_ADDR", ":9090"),
+		InternalAPIAddr:                 getEnv("INTERNAL_API_ADDR", ":9091"),
+		MetricsAddr:                     getEnv("METRICS_ADDR", ":9092"),
+		ASRAddr:                         getEnv("ASR_ADDR", "localhost:50051"),
+		TTSAddr:                         getEnv("TTS_ADDR", "localhost:50052"),
+		RingBufferSec:                   getEnvInt("RING_BUFFER_SEC", 60),
+		STUNServers:                     getEnvList("STUN_SERVERS", []string{"stun:stun.l.google.com:19302"}),
+		MaxSessions:                     getEnvInt("MAX_SESSIONS", 100),
+		MaxLookbackSec:                  getEnvInt("MAX_LOOKBACK_SEC", 60),
+		ActionTimeoutSec:                getEnvInt("ACTION_TIMEOUT_SEC", 60),
+		MaxInferenceConcurrency:         getEnvInt("MAX_INFERENCE_CONCURRENCY", 4),
+		MaxIngestDurationSec:            getEnvInt("MAX_INGEST_DURATION_SEC", 1800),
+		IngestDuckDB:                    getEnvInt("INGEST_DUCK_DB", -15),
+		IngestReconnectMaxAttempts:      getEnvInt("INGEST_RECONNECT_MAX_ATTEMPTS", 5),
+		IngestReconnectInitialBackoffMs: getEnvInt("INGEST_RECONNECT_INITIAL_BACKOFF_MS", 1000),
+		IngestReconnectMaxBackoffMs:     getEnvInt("INGEST_RECONNECT_MAX_BACKOFF_MS", 30000),
+		IngestReconnectJitterPct:        getEnvInt("INGEST_RECONNECT_JITTER_PCT", 20),
+		MediaLibraryDir:                 getEnv("MEDIA_LIBRARY_DIR", ""),
+		S3Endpoint:                      getEnv("S3_ENDPOINT", ""),
+		S3Region:                        getEnv("S3_REGION", "us-east-1"),
+		S3AccessKeyID:                   getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:               getEnv("S3_SECRET_ACCESS_KEY", ""),
+		CaptionWindowSec:                getEnvInt("CAPTION_WINDOW_SEC", 3),
+		ASRNormalize:                    getEnvBool("ASR_NORMALIZE", true),
+		ASRTargetLUFS:                   getEnvInt("ASR_TARGET_LUFS", -23),
+		ASRMaxGainDB:                    getEnvInt("ASR_MAX_GAIN_DB", 20),
+		ASRHighPass:                     getEnvBool("ASR_HIGH_PASS", true),
+		TTSNormalize:                    getEnvBool("TTS_NORMALIZE", true),
+		TTSTargetLUFS:                   getEnvInt("TTS_TARGET_LUFS", -18),
+		TTSMaxGainDB:                    getEnvInt("TTS_MAX_GAIN_DB", 12),
+		JitterMaxDelayMs:                getEnvInt("JITTER_MAX_DELAY_MS", 120),
+		DVRDir:                          getEnv("DVR_DIR", ""),
+		DVRSec:                          getEnvInt("DVR_SEC", 1800),
+		DVRSegmentSec:                   getEnvInt("DVR_SEGMENT_SEC", 10),
 	}
 }
 
@@ -62,3 +123,12 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
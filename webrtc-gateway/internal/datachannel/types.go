@@ -13,47 +13,114 @@ type Envelope struct {
 
 // CommandEnunciate is the payload for command.enunciate messages.
 type CommandEnunciate struct {
-	LookbackSeconds int        `json:"lookbackSeconds"`
-	TargetLanguage  string     `json:"targetLanguage,omitempty"`
-	TTSOptions      TTSOptions `json:"ttsOptions,omitempty"`
+	LookbackSeconds int `json:"lookbackSeconds"`
+	// Utterances, when > 0, snapshots the last N detected speech segments
+	// instead of LookbackSeconds of audio.
+	Utterances int `json:"utterances,omitempty"`
+	// FromMs/ToMs, when both set, snapshot an exact range of the stream timeline
+	// (milliseconds since the session's first buffered audio) instead.
+	FromMs *int `json:"fromMs,omitempty"`
+	ToMs   *int `json:"toMs,omitempty"`
+	// BookmarkID, when set, snapshots LookbackSeconds of audio starting at
+	// that bookmark instead of ending now.
+	BookmarkID     string     `json:"bookmarkId,omitempty"`
+	TargetLanguage string     `json:"targetLanguage,omitempty"`
+	TTSOptions     TTSOptions `json:"ttsOptions,omitempty"`
 	// Text-only mode (Spotify): skip ring buffer + ASR,
 	// go straight to translate → TTS with the provided lyrics text.
 	Text           string `json:"text,omitempty"`
 	SourceLanguage string `json:"sourceLanguage,omitempty"`
 }
 
+// CommandUpdate is the payload for command.update messages.
+// Without an actionId it changes session defaults; with one it changes the in-flight action.
+type CommandUpdate struct {
+	// TargetLanguage is nil when omitted; an empty string clears the default.
+	TargetLanguage *string    `json:"targetLanguage,omitempty"`
+	TTSOptions     TTSOptions `json:"ttsOptions,omitempty"`
+}
+
+// CommandBookmark is the payload for command.bookmark messages.
+// The command's actionId becomes the bookmark ID.
+type CommandBookmark struct {
+	Label string `json:"label,omitempty"`
+}
+
+// CommandCaptionStart is the payload for command.caption.start messages.
+type CommandCaptionStart struct {
+	// WindowSeconds is how much new audio is collected per ASR call (default CAPTION_WINDOW_SEC).
+	WindowSeconds  int    `json:"windowSeconds,omitempty"`
+	Language       string `json:"language,omitempty"`
+	TargetLanguage string `json:"targetLanguage,omitempty"`
+}
+
 // TTSOptions controls text-to-speech synthesis parameters.
 type TTSOptions struct {
 	Voice string  `json:"voice,omitempty"`
 	Speed float64 `json:"speed,omitempty"`
+	// Volume is a linear gain on the spoken audio in (0, 4]; 0 leaves it at 1.
+	Volume float64 `json:"volume,omitempty"`
+	// Streaming starts playback on the first TTS chunk instead of buffering the
+	// whole utterance. Word marks then arrive afterwards as tts.marks.update.
+	// nil leaves it to the session default (buffered if unset).
+	Streaming *bool `json:"streaming,omitempty"`
+}
+
+// IsStreaming reports whether streaming playback is selected.
+func (o TTSOptions) IsStreaming() bool {
+	return o.Streaming != nil && *o.Streaming
+}
+
+// EventAsrPartial is the payload for asr.partial events.
+// Text is cumulative: each partial replaces the previous one.
+type EventAsrPartial struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
 }
 
 // EventAsrFinal is the payload for asr.final events.
 type EventAsrFinal struct {
 	Text           string    `json:"text"`
 	Language       string    `json:"language"`
-	TranslatedText string   `json:"translatedText,omitempty"`
-	TargetLanguage string   `json:"targetLanguage,omitempty"`
+	TranslatedText string    `json:"translatedText,omitempty"`
+	TargetLanguage string    `json:"targetLanguage,omitempty"`
 	Segments       []Segment `json:"segments,omitempty"`
 	InferenceMs    int       `json:"inferenceMs,omitempty"`
 	TranslateMs    int       `json:"translateMs,omitempty"`
+	// StartTimestamp/EndTimestamp are the wall-clock capture times (Unix ms)
+	// of the first and last transcribed sample.
+	StartTimestamp int64 `json:"startTimestamp,omitempty"`
+	EndTimestamp   int64 `json:"endTimestamp,omitempty"`
+	// Preprocessing reports the conditioning applied to the audio before ASR.
+	Preprocessing *AsrPreprocessing `json:"preprocessing,omitempty"`
+}
+
+// AsrPreprocessing describes the gain applied to a snapshot before ASR.
+type AsrPreprocessing struct {
+	// InputLufs is the snapshot's measured loudness; omitted for silence.
+	InputLufs      *float64 `json:"inputLufs,omitempty"`
+	GainDb         float64  `json:"gainDb"`
+	LimitedSamples int      `json:"limitedSamples"`
 }
 
 // Segment is a time-aligned piece of transcription.
+// StartTime/EndTime are seconds into the snapshot; the timestamps are wall-clock Unix ms.
 type Segment struct {
-	Text       string  `json:"text"`
-	StartTime  float64 `json:"startTime"`
-	EndTime    float64 `json:"endTime"`
-	Confidence float64 `json:"confidence,omitempty"`
+	Text           string  `json:"text"`
+	StartTime      float64 `json:"startTime"`
+	EndTime        float64 `json:"endTime"`
+	Confidence     float64 `json:"confidence,omitempty"`
+	StartTimestamp int64   `json:"startTimestamp,omitempty"`
+	EndTimestamp   int64   `json:"endTimestamp,omitempty"`
 }
 
 // EventMetricsLatency is the payload for metrics.latency events.
 type EventMetricsLatency struct {
-	SnapshotMs     float64 `json:"snapshotMs"`
-	AsrMs          float64 `json:"asrMs"`
-	TranslateMs    float64 `json:"translateMs,omitempty"`
+	SnapshotMs      float64 `json:"snapshotMs"`
+	AsrMs           float64 `json:"asrMs"`
+	TranslateMs     float64 `json:"translateMs,omitempty"`
 	TtsFirstChunkMs float64 `json:"ttsFirstChunkMs"`
-	TotalMs        float64 `json:"totalMs"`
+	TotalMs         float64 `json:"totalMs"`
 }
 
 // WordMark represents a single word's estimated timing in the TTS audio.
@@ -63,8 +130,9 @@ type WordMark struct {
 	EndMs   float64 `json:"endMs"`
 }
 
-// EventTtsMarks is the payload for tts.marks events.
-// Sent before audio playback begins so the client can highlight words.
+// EventTtsMarks is the payload for tts.marks and tts.marks.update events.
+// tts.marks is sent before audio playback begins so the client can highlight words;
+// tts.marks.update is sent after synthesis completes when streaming playback is used.
 type EventTtsMarks struct {
 	Text       string     `json:"text"`
 	Words      []WordMark `json:"words"`
@@ -76,6 +144,53 @@ type EventTtsDone struct {
 	DurationMs int `json:"durationMs"`
 }
 
+// EventUpdateApplied is the payload for update.applied events, acknowledging a
+// command.update with the values now in effect for its scope.
+type EventUpdateApplied struct {
+	Scope          string     `json:"scope"` // "session" or "action"
+	TargetLanguage string     `json:"targetLanguage,omitempty"`
+	TTSOptions     TTSOptions `json:"ttsOptions"`
+	// NotApplied names requested ttsOptions fields the in-flight action could
+	// not take because its synthesis had already started.
+	NotApplied []string `json:"notApplied,omitempty"`
+}
+
+// EventActionCancelled is the payload for action.cancelled events.
+type EventActionCancelled struct {
+	Reason string `json:"reason"`
+}
+
+// EventCaptionSegment is the payload for caption.segment events.
+// Segments never overlap and are never revised; StartMs/EndMs are ring buffer
+// stream offsets in milliseconds.
+type EventCaptionSegment struct {
+	Seq            int     `json:"seq"`
+	Text           string  `json:"text"`
+	Language       string  `json:"language"`
+	TranslatedText string  `json:"translatedText,omitempty"`
+	TargetLanguage string  `json:"targetLanguage,omitempty"`
+	StartMs        float64 `json:"startMs"`
+	EndMs          float64 `json:"endMs"`
+	// StartTimestamp/EndTimestamp are the wall-clock capture times (Unix ms) of the window.
+	StartTimestamp int64 `json:"startTimestamp,omitempty"`
+	EndTimestamp   int64 `json:"endTimestamp,omitempty"`
+}
+
+// EventBookmarkCreated is the payload for bookmark.created events.
+type EventBookmarkCreated struct {
+	BookmarkID string `json:"bookmarkId"`
+	Label      string `json:"label,omitempty"`
+	// OffsetMs is the position on the ring buffer stream timeline.
+	OffsetMs float64 `json:"offsetMs"`
+	// CapturedAt is the wall-clock capture time of the bookmarked moment (Unix ms).
+	CapturedAt int64 `json:"capturedAt,omitempty"`
+}
+
+// EventCaptionStopped is the payload for caption.stopped events.
+type EventCaptionStopped struct {
+	Reason string `json:"reason"`
+}
+
 // EventError is the payload for error events.
 type EventError struct {
 	Code    string      `json:"code"`
@@ -85,7 +200,29 @@ type EventError struct {
 
 // EventIngestStarted is the payload for ingest.started events.
 type EventIngestStarted struct {
-	URL string `json:"url"`
+	Type string `json:"type,omitempty"`
+	URL  string `json:"url"`
+}
+
+// EventIngestReconnecting is the payload for ingest.reconnecting events, sent
+// when a dropped source is about to be restarted.
+type EventIngestReconnecting struct {
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"maxAttempts"`
+	DelayMs     int64  `json:"delayMs"`
+	Error       string `json:"error"`
+}
+
+// EventIngestMetadata is the payload for ingest.metadata events, sent when the
+// ingested stream's title or artist changes.
+type EventIngestMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Artist      string `json:"artist,omitempty"`
+	StreamTitle string `json:"streamTitle,omitempty"`
+	// OffsetMs is where the change takes effect on the ring buffer stream timeline.
+	OffsetMs float64 `json:"offsetMs"`
+	// CapturedAt is the wall-clock capture time of that audio (Unix ms).
+	CapturedAt int64 `json:"capturedAt,omitempty"`
 }
 
 // EventIngestStopped is the payload for ingest.stopped events.
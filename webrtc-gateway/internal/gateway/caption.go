@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/metrics"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/session"
+)
+
+// captionPollInterval is how often the caption loop checks for new audio.
+const captionPollInterval = 250 * time.Millisecond
+
+// makeCaptionStartHandler returns a datachannel.Handler for command.caption.start.
+// Captioning runs alongside (not as) an action, so it never cancels an enunciate.
+func (gw *Gateway) makeCaptionStartHandler(sess *session.Session) datachannel.Handler {
+	return func(sessionID, actionID string, payload json.RawMessage) error {
+		var cmd datachannel.CommandCaptionStart
+		if len(payload) > 0 {
+			if err := json.Unmarshal(payload, &cmd); err != nil {
+				gw.logger.Warn("invalid caption.start payload", zap.Error(err))
+				gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", "invalid command.caption.start payload")
+				return err
+			}
+		}
+
+		ctx, ok := sess.StartCaptions()
+		if !ok {
+			gw.sendError(sess, sessionID, actionID, "CAPTION_ALREADY_RUNNING", "live captioning is already running")
+			return nil
+		}
+
+		go gw.captionLoop(ctx, sess, sessionID, cmd)
+		return nil
+	}
+}
+
+// makeCaptionStopHandler returns a datachannel.Handler for command.caption.stop.
+func (gw *Gateway) makeCaptionStopHandler(sess *session.Session) datachannel.Handler {
+	return func(sessionID, actionID string, payload json.RawMessage) error {
+		if !sess.StopCaptions() {
+			return nil
+		}
+		stoppedPayload, _ := json.Marshal(datachannel.EventCaptionStopped{Reason: "client"})
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "caption.stopped",
+			SessionID: sessionID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(stoppedPayload),
+		})
+		return nil
+	}
+}
+
+// captionLoop feeds newly written ring buffer audio to ASR in consecutive,
+// non-overlapping windows and emits caption.segment events. It tracks the ring
+// buffer write position so no audio is transcribed twice. Runs until ctx is
+// cancelled by StopCaptions or session stop.
+func (gw *Gateway) captionLoop(ctx context.Context, sess *session.Session, sessionID string,
+	cmd datachannel.CommandCaptionStart) {
+
+	logger := gw.logger.With(zap.String("session", sessionID))
+
+	window := cmd.WindowSeconds
+	if window <= 0 {
+		window = gw.cfg.CaptionWindowSec
+	}
+	if window > gw.cfg.MaxLookbackSec {
+		window = gw.cfg.MaxLookbackSec
+	}
+	windowBytes := window * ringbuffer.BytesPerSecond
+	maxBytes := gw.cfg.MaxLookbackSec * ringbuffer.BytesPerSecond
+
+	metrics.ActiveCaptions.Inc()
+	defer metrics.ActiveCaptions.Dec()
+	logger.Info("live captioning started", zap.Int("windowSec", window))
+	defer logger.Info("live captioning stopped")
+
+	ticker := time.NewTicker(captionPollInterval)
+	defer ticker.Stop()
+
+	offset := sess.RingBuffer.Written()
+	seq := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pending := sess.RingBuffer.Written() - offset
+		if pending < windowBytes {
+			continue
+		}
+
+		// Fast-fail like enunciate: when inference is saturated leave the audio
+		// pending and retry on the next tick with a larger window.
+		select {
+		case gw.inferenceSem <- struct{}{}:
+			metrics.InferenceSemUsed.Inc()
+		default:
+			metrics.CaptionWindowsTotal.WithLabelValues("busy").Inc()
+			continue
+		}
+
+		// Too far behind (ASR slower than realtime): drop the backlog rather
+		// than let captions drift further from live.
+		if pending > maxBytes {
+			logger.Warn("caption backlog dropped", zap.Int("bytes", pending-windowBytes))
+			offset = sess.RingBuffer.Written() - windowBytes
+			metrics.CaptionWindowsTotal.WithLabelValues("dropped").Inc()
+		}
+
+		bufPtr := gw.snapshotPool.Get().(*[]byte)
+		pcm, start := sess.RingBuffer.ReadSince(offset, *bufPtr)
+		end := start + len(pcm)
+		timeline := sess.RingBuffer.Timeline(start, end)
+		gw.preprocessForASR(sess, pcm, logger)
+
+		resp, err := gw.inferenceClient.Transcribe(ctx, pcm, sessionID, "", cmd.Language, "transcribe", cmd.TargetLanguage)
+		gw.snapshotPool.Put(bufPtr)
+		<-gw.inferenceSem
+		metrics.InferenceSemUsed.Dec()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("caption ASR failed", zap.Error(err))
+			metrics.CaptionWindowsTotal.WithLabelValues("error").Inc()
+			// Skip this window so a persistent failure can't pin the loop on it.
+			offset = end
+			continue
+		}
+		offset = end
+
+		// Windows don't overlap, so each transcript is new speech as-is.
+		text := strings.TrimSpace(resp.Text)
+		if text == "" {
+			metrics.CaptionWindowsTotal.WithLabelValues("empty").Inc()
+			continue
+		}
+
+		segPayload, _ := json.Marshal(datachannel.EventCaptionSegment{
+			Seq:            seq,
+			Text:           text,
+			Language:       resp.Language,
+			TranslatedText: resp.TranslatedText,
+			TargetLanguage: resp.TargetLanguage,
+			StartMs:        streamOffsetMs(start),
+			EndMs:          streamOffsetMs(end),
+			StartTimestamp: unixMilli(timeline.TimeAt(start)),
+			EndTimestamp:   unixMilli(timeline.TimeAt(end)),
+		})
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "caption.segment",
+			SessionID: sessionID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(segPayload),
+		})
+		seq++
+		metrics.CaptionWindowsTotal.WithLabelValues("segment").Inc()
+	}
+}
+
+// streamOffsetMs converts an absolute ring buffer byte offset to milliseconds.
+func streamOffsetMs(offset int) float64 {
+	return float64(offset) * 1000 / float64(ringbuffer.BytesPerSecond)
+}
+
+// unixMilli converts a capture time to Unix ms, keeping 0 for an unknown time.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
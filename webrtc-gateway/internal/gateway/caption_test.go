@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/inference"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+func TestCaptionSegmentsKeepRepeatedWords(t *testing.T) {
+	// Every window transcribes to the same words; as the windows don't
+	// overlap, each is new speech and must come out whole.
+	gw := newASRTestGateway(&inference.MockClient{TranscribeText: "it was late."})
+	sess := newTestSession(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		gw.captionLoop(ctx, sess.Session, "sess-1", datachannel.CommandCaptionStart{})
+		close(done)
+	}()
+	// The loop starts from the write position when it starts.
+	time.Sleep(50 * time.Millisecond)
+	sess.RingBuffer.Write(make([]byte, ringbuffer.BytesPerSecond))
+	waitEvents(t, sess.rec, "caption.segment", 1)
+	sess.RingBuffer.Write(make([]byte, ringbuffer.BytesPerSecond))
+	waitEvents(t, sess.rec, "caption.segment", 2)
+	cancel()
+	<-done
+
+	var seg datachannel.EventCaptionSegment
+	payload(t, sess.rec, "caption.segment", &seg)
+	if seg.Seq != 1 || seg.Text != "it was late." || seg.EndMs-seg.StartMs != 1000 {
+		t.Errorf("second segment = %+v", seg)
+	}
+}
+
+// waitEvents waits until n events of the given type have been recorded.
+func waitEvents(t *testing.T, rec *recorder, typ string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for countEvents(rec, typ) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d %s events, want %d: %v", countEvents(rec, typ), typ, n, rec.types())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
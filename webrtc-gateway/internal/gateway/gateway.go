@@ -3,7 +3,11 @@ package gateway
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -14,10 +18,13 @@ import (
 	"github.com/pion/webrtc/v4"
 	"go.uber.org/zap"
 
+	whatsv1 "github.com/RenatoCabral2022/WhatsWebService/gen/go/whats/v1"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/config"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/inference"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ingest"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/jitter"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/metrics"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/session"
@@ -25,6 +32,13 @@ import (
 
 const iceGatherTimeout = 10 * time.Second
 
+// speechPadMs is kept around detected speech when trimming snapshots, so word
+// onsets and trailing consonants the VAD misses are not cut off.
+const speechPadMs = 200
+
+// maxTTSVolume is the largest ttsOptions.volume accepted (+12 dB).
+const maxTTSVolume = 4.0
+
 // Gateway manages WebRTC connections and orchestrates the audio pipeline.
 type Gateway struct {
 	cfg             *config.Config
@@ -33,6 +47,7 @@ type Gateway struct {
 	inferenceClient inference.InferenceClient
 	inferenceSem    chan struct{}
 	snapshotPool    sync.Pool
+	ingestSources   *ingest.Registry
 
 	mu       sync.RWMutex
 	sessions map[string]*session.Session
@@ -96,6 +111,15 @@ func newGateway(cfg *config.Config, api *webrtc.API, logger *zap.Logger, infClie
 				return &buf
 			},
 		},
+		ingestSources: ingest.NewDefaultRegistry(ingest.SourceConfig{
+			MediaLibraryDir: cfg.MediaLibraryDir,
+			S3: ingest.S3Config{
+				Endpoint:        cfg.S3Endpoint,
+				Region:          cfg.S3Region,
+				AccessKeyID:     cfg.S3AccessKeyID,
+				SecretAccessKey: cfg.S3SecretAccessKey,
+			},
+		}),
 		sessions: make(map[string]*session.Session),
 	}
 }
@@ -114,19 +138,51 @@ func (gw *Gateway) ICEServers() []webrtc.ICEServer {
 	return []webrtc.ICEServer{{URLs: urls}}
 }
 
+// SessionOptions are the per-session settings chosen at creation.
+type SessionOptions struct {
+	// Encoder configures the outbound Opus encoder.
+	Encoder audio.EncoderProfile
+	// ASRPreprocessing conditions audio snapshots before they are transcribed.
+	ASRPreprocessing audio.PreprocessOptions
+	// TTSLoudness is the level TTS playback is normalized to (nil: off).
+	TTSLoudness *audio.LoudnessTarget
+}
+
+// DefaultSessionOptions returns the options used when a session specifies none.
+func (gw *Gateway) DefaultSessionOptions() SessionOptions {
+	opts := SessionOptions{
+		Encoder: audio.VoiceProfile,
+		ASRPreprocessing: audio.PreprocessOptions{
+			Normalize:  gw.cfg.ASRNormalize,
+			TargetLUFS: float64(gw.cfg.ASRTargetLUFS),
+			MaxGainDB:  float64(gw.cfg.ASRMaxGainDB),
+			HighPass:   gw.cfg.ASRHighPass,
+		},
+	}
+	if gw.cfg.TTSNormalize {
+		opts.TTSLoudness = &audio.LoudnessTarget{
+			TargetLUFS: float64(gw.cfg.TTSTargetLUFS),
+			MaxGainDB:  float64(gw.cfg.TTSMaxGainDB),
+		}
+	}
+	return opts
+}
+
 // CreateSession sets up a full WebRTC PeerConnection with inbound/outbound audio.
 // Returns the SDP offer string for the client to answer.
-func (gw *Gateway) CreateSession(id string) (string, error) {
+func (gw *Gateway) CreateSession(id string, opts SessionOptions) (string, error) {
 	logger := gw.logger.With(zap.String("session", id))
 
 	sess := session.New(id, gw.cfg.RingBufferSec, gw.logger)
+	sess.SetASRPreprocessing(opts.ASRPreprocessing)
+	sess.SetTTSLoudness(opts.TTSLoudness)
 
 	// Create Opus decoder + encoder
 	dec, err := audio.NewDecoder()
 	if err != nil {
 		return "", fmt.Errorf("create opus decoder: %w", err)
 	}
-	enc, err := audio.NewEncoder()
+	enc, err := audio.NewEncoder(opts.Encoder)
 	if err != nil {
 		return "", fmt.Errorf("create opus encoder: %w", err)
 	}
@@ -160,6 +216,8 @@ func (gw *Gateway) CreateSession(id string) (string, error) {
 	}
 
 	sess.SetPeerConnection(pc, track)
+	sess.SetDuckLevel(float64(gw.cfg.IngestDuckDB))
+	sess.StartOutbound()
 
 	// Create data channel on server side (must be before CreateOffer so SCTP is in SDP)
 	ordered := true
@@ -172,6 +230,11 @@ func (gw *Gateway) CreateSession(id string) (string, error) {
 
 	router := datachannel.NewRouter()
 	router.Register("command.enunciate", gw.makeEnunciateHandler(sess))
+	router.Register("command.update", gw.makeUpdateHandler(sess))
+	router.Register("command.cancel", gw.makeCancelHandler(sess))
+	router.Register("command.caption.start", gw.makeCaptionStartHandler(sess))
+	router.Register("command.caption.stop", gw.makeCaptionStopHandler(sess))
+	router.Register("command.bookmark", gw.makeBookmarkHandler(sess))
 	sess.SetRouter(router)
 
 	dc.OnOpen(func() {
@@ -223,6 +286,8 @@ func (gw *Gateway) CreateSession(id string) (string, error) {
 
 	sdp := pc.LocalDescription().SDP
 
+	gw.attachDVR(sess, logger)
+
 	// Store session
 	gw.mu.Lock()
 	gw.sessions[id] = sess
@@ -302,21 +367,105 @@ func (gw *Gateway) Shutdown() {
 	gw.logger.Info("gateway shutdown complete")
 }
 
-// inboundAudioLoop reads RTP packets from a remote track and feeds them into the session.
+// jitterMinDelay is the shortest time a gap in the inbound stream is held open
+// for a reordered packet: one frame.
+const jitterMinDelay = audio.FrameDurationMs * time.Millisecond
+
+// inboundAudioLoop reads RTP packets from a remote track, reorders them through a
+// jitter buffer, and feeds them into the session in sequence order.
 func (gw *Gateway) inboundAudioLoop(sess *session.Session, track *webrtc.TrackRemote) {
 	logger := gw.logger.With(zap.String("session", sess.ID))
 	logger.Info("inbound audio loop started")
 
+	packets := make(chan jitter.Packet, 64)
+	go func() {
+		defer close(packets)
+		for {
+			pkt, _, err := track.ReadRTP()
+			if err != nil {
+				logger.Info("inbound audio loop ended", zap.Error(err))
+				return
+			}
+			packets <- jitter.Packet{
+				SequenceNumber: pkt.SequenceNumber,
+				Timestamp:      pkt.Timestamp,
+				Payload:        pkt.Payload,
+			}
+		}
+	}()
+
+	jb := jitter.NewBuffer(jitterMinDelay, time.Duration(gw.cfg.JitterMaxDelayMs)*time.Millisecond)
+	deliver := func(p jitter.Packet) {
+		sess.HandleInboundRTP(p.SequenceNumber, p.Timestamp, p.Payload)
+	}
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
 	for {
-		pkt, _, err := track.ReadRTP()
-		if err != nil {
-			logger.Info("inbound audio loop ended", zap.Error(err))
-			return
+		select {
+		case pkt, ok := <-packets:
+			if !ok {
+				timer.Stop()
+				for _, p := range jb.Drain() {
+					deliver(p)
+				}
+				return
+			}
+			switch jb.Push(pkt, time.Now()) {
+			case jitter.Reordered:
+				metrics.RTPJitterPacketsTotal.WithLabelValues("reordered").Inc()
+			case jitter.Late:
+				metrics.RTPJitterPacketsTotal.WithLabelValues("late").Inc()
+			case jitter.Duplicate:
+				metrics.RTPJitterPacketsTotal.WithLabelValues("duplicate").Inc()
+			}
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for p, ok := jb.Pop(now); ok; p, ok = jb.Pop(now) {
+			deliver(p)
+		}
+
+		// Wake up to skip a gap even if no further packets arrive.
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if deadline := jb.NextDeadline(); !deadline.IsZero() {
+			timer.Reset(time.Until(deadline))
 		}
-		sess.HandleInboundRTP(pkt.SequenceNumber, pkt.Payload)
 	}
 }
 
+// dvrSessionIDPattern restricts the session IDs used as DVR directory names,
+// so a crafted ID (e.g. "..") can never point outside DVR_DIR.
+var dvrSessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// attachDVR adds a disk tier to the session's ring buffer when DVR_DIR is set,
+// so older audio can be rewound beyond RING_BUFFER_SEC. Failure is not fatal:
+// the session keeps working from memory only.
+func (gw *Gateway) attachDVR(sess *session.Session, logger *zap.Logger) {
+	if gw.cfg.DVRDir == "" {
+		return
+	}
+	if !dvrSessionIDPattern.MatchString(sess.ID) {
+		logger.Warn("session id not usable as DVR directory name, DVR disabled")
+		return
+	}
+	tier, err := ringbuffer.NewDiskTier(filepath.Join(gw.cfg.DVRDir, sess.ID), gw.cfg.DVRSec, gw.cfg.DVRSegmentSec)
+	if err != nil {
+		logger.Warn("DVR disabled", zap.Error(err))
+		return
+	}
+	sess.RingBuffer.SetDiskTier(tier, func(err error) {
+		logger.Warn("DVR disk tier failed, continuing from memory", zap.Error(err))
+		metrics.DVRDiskErrorsTotal.Inc()
+	})
+}
+
 // makeEnunciateHandler returns a datachannel.Handler that orchestrates the enunciate pipeline.
 func (gw *Gateway) makeEnunciateHandler(sess *session.Session) datachannel.Handler {
 	return func(sessionID, actionID string, payload json.RawMessage) error {
@@ -332,15 +481,187 @@ func (gw *Gateway) makeEnunciateHandler(sess *session.Session) datachannel.Handl
 			return err
 		}
 
+		if msg := gw.validateEnunciateRange(cmd); msg != "" {
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", msg)
+			return nil
+		}
+		if v := cmd.TTSOptions.Volume; v < 0 || v > maxTTSVolume {
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND",
+				fmt.Sprintf("ttsOptions.volume %.2f out of range (0, %g]", v, maxTTSVolume))
+			return nil
+		}
+
+		// Fill unset fields from session defaults (command.update)
+		defaults := sess.Defaults()
+		if cmd.TargetLanguage == "" {
+			cmd.TargetLanguage = defaults.TargetLanguage
+		}
+		cmd.TTSOptions = withTTSDefaults(cmd.TTSOptions, defaults.TTSOptions)
+
 		// Claim action slot with timeout (auto-cancels previous)
 		timeout := time.Duration(gw.cfg.ActionTimeoutSec) * time.Second
 		ctx := sess.TryStartAction(actionID, timeout)
+		sess.SetActionTTSOptions(actionID, cmd.TTSOptions)
 
 		go gw.executeEnunciate(ctx, sess, sessionID, actionID, cmd)
 		return nil
 	}
 }
 
+// withTTSDefaults fills the TTS options an enunciate leaves unset from the
+// session defaults.
+func withTTSDefaults(opts, defaults datachannel.TTSOptions) datachannel.TTSOptions {
+	if opts.Voice == "" {
+		opts.Voice = defaults.Voice
+	}
+	if opts.Speed <= 0 {
+		opts.Speed = defaults.Speed
+	}
+	if opts.Volume <= 0 {
+		opts.Volume = defaults.Volume
+	}
+	if opts.Streaming == nil {
+		opts.Streaming = defaults.Streaming
+	}
+	return opts
+}
+
+// makeBookmarkHandler returns a datachannel.Handler for command.bookmark.
+// It marks the current ring buffer position under the command's actionId so a
+// later command.enunciate can start from it via bookmarkId.
+func (gw *Gateway) makeBookmarkHandler(sess *session.Session) datachannel.Handler {
+	return func(sessionID, actionID string, payload json.RawMessage) error {
+		var cmd datachannel.CommandBookmark
+		if len(payload) > 0 {
+			if err := json.Unmarshal(payload, &cmd); err != nil {
+				gw.logger.Warn("invalid bookmark payload", zap.Error(err))
+				gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", "invalid command.bookmark payload")
+				return err
+			}
+		}
+		if actionID == "" {
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", "command.bookmark requires an actionId")
+			return nil
+		}
+
+		bm := sess.AddBookmark(actionID, cmd.Label)
+		createdPayload, _ := json.Marshal(datachannel.EventBookmarkCreated{
+			BookmarkID: bm.ID,
+			Label:      bm.Label,
+			OffsetMs:   streamOffsetMs(bm.Offset),
+			CapturedAt: unixMilli(bm.At),
+		})
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "bookmark.created",
+			SessionID: sessionID,
+			ActionID:  actionID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(createdPayload),
+		})
+		return nil
+	}
+}
+
+// makeUpdateHandler returns a datachannel.Handler for command.update.
+// Without an actionId it updates session defaults; with one it updates the
+// TTS options of the in-flight action. Replies with update.applied.
+func (gw *Gateway) makeUpdateHandler(sess *session.Session) datachannel.Handler {
+	return func(sessionID, actionID string, payload json.RawMessage) error {
+		var cmd datachannel.CommandUpdate
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			gw.logger.Warn("invalid update payload", zap.Error(err))
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", "invalid command.update payload")
+			return err
+		}
+		if s := cmd.TTSOptions.Speed; s != 0 && (s < 0.5 || s > 2.0) {
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND",
+				fmt.Sprintf("ttsOptions.speed %.2f out of range [0.5, 2.0]", s))
+			return nil
+		}
+		if v := cmd.TTSOptions.Volume; v < 0 || v > maxTTSVolume {
+			gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND",
+				fmt.Sprintf("ttsOptions.volume %.2f out of range (0, %g]", v, maxTTSVolume))
+			return nil
+		}
+
+		applied := datachannel.EventUpdateApplied{}
+		var marks *datachannel.EventTtsMarks
+		if actionID == "" {
+			defaults := sess.UpdateDefaults(cmd.TargetLanguage, cmd.TTSOptions)
+			applied.Scope = "session"
+			applied.TargetLanguage = defaults.TargetLanguage
+			applied.TTSOptions = defaults.TTSOptions
+		} else {
+			update, ok := sess.UpdateActionTTSOptions(actionID, cmd.TTSOptions)
+			if !ok {
+				gw.sendError(sess, sessionID, actionID, "ACTION_NOT_ACTIVE", "no in-flight action with this actionId")
+				return nil
+			}
+			applied.Scope = "action"
+			applied.TTSOptions = update.TTSOptions
+			applied.NotApplied = update.NotApplied
+			marks = update.Marks
+		}
+
+		gw.logger.Info("update applied",
+			zap.String("session", sessionID),
+			zap.String("action", actionID),
+			zap.String("scope", applied.Scope),
+		)
+
+		appliedPayload, _ := json.Marshal(applied)
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "update.applied",
+			SessionID: sessionID,
+			ActionID:  actionID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(appliedPayload),
+		})
+		// Words not yet played move with a new playback rate.
+		if marks != nil {
+			sendMarks(sess, sessionID, actionID, "tts.marks.update", *marks)
+		}
+		return nil
+	}
+}
+
+// makeCancelHandler returns a datachannel.Handler for command.cancel.
+// It cancels the in-flight action (or the one named by actionId) and replies
+// with action.cancelled; the pipeline records the user_cancelled outcome.
+func (gw *Gateway) makeCancelHandler(sess *session.Session) datachannel.Handler {
+	return func(sessionID, actionID string, payload json.RawMessage) error {
+		cancelledID, ok := sess.CancelAction(actionID)
+		if !ok {
+			gw.sendError(sess, sessionID, actionID, "ACTION_NOT_ACTIVE", "no in-flight action to cancel")
+			return nil
+		}
+
+		gw.logger.Info("action cancelled by client",
+			zap.String("session", sessionID),
+			zap.String("action", cancelledID),
+		)
+
+		cancelledPayload, _ := json.Marshal(datachannel.EventActionCancelled{Reason: "client"})
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "action.cancelled",
+			SessionID: sessionID,
+			ActionID:  cancelledID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(cancelledPayload),
+		})
+		return nil
+	}
+}
+
+// cancelledOutcome returns the ActionsTotal outcome for a cancelled action context,
+// distinguishing an explicit command.cancel from supersede/session stop.
+func cancelledOutcome(ctx context.Context) string {
+	if errors.Is(context.Cause(ctx), session.ErrActionCancelled) {
+		return "user_cancelled"
+	}
+	return "cancelled"
+}
+
 // executeEnunciate runs the full enunciate pipeline: snapshot → ASR → TTS → playback.
 // When cmd.Text is provided (Spotify mode), skips snapshot + ASR and uses text directly.
 func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
@@ -440,98 +761,11 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 			return
 		}
 
-		// TTS streaming (inline — same logic as audio-based path)
-		ttsStart := time.Now()
-		voice := cmd.TTSOptions.Voice
-		if voice == "" {
-			voice = "default"
-		}
-		speed := float32(cmd.TTSOptions.Speed)
-		if speed <= 0 {
-			speed = 1.0
-		}
-
-		rawChunks, errs := gw.inferenceClient.SynthesizeStream(ctx, ttsText,
-			sessionID, actionID, voice, ttsLang, speed)
-
-		var allChunks [][]byte
-		var totalPCMBytes int
-		var ttsFirstChunkMs float64
-		first := true
-		for chunk := range rawChunks {
-			if first {
-				ttsFirstChunkMs = float64(time.Since(ttsStart).Microseconds()) / 1000.0
-				first = false
-			}
-			allChunks = append(allChunks, chunk)
-			totalPCMBytes += len(chunk)
-			if ctx.Err() != nil {
-				break
-			}
-		}
-
-		if totalPCMBytes > 0 {
-			totalDurationMs := float64(totalPCMBytes) / 32.0
-			marks := calculateWordMarks(ttsText, totalDurationMs)
-			marksPayload, _ := json.Marshal(datachannel.EventTtsMarks{
-				Text:       ttsText,
-				Words:      marks,
-				DurationMs: totalDurationMs,
-			})
-			sess.SendDataChannelMessage(datachannel.Envelope{
-				Type:      "tts.marks",
-				SessionID: sessionID,
-				ActionID:  actionID,
-				Timestamp: time.Now().UnixMilli(),
-				Payload:   json.RawMessage(marksPayload),
-			})
-		}
-
-		sess.SendDataChannelMessage(datachannel.Envelope{
-			Type:      "tts.started",
-			SessionID: sessionID,
-			ActionID:  actionID,
-			Timestamp: time.Now().UnixMilli(),
-			Payload:   json.RawMessage(`{"voice":"default"}`),
-		})
-
-		bufferedCh := make(chan []byte, len(allChunks))
-		for _, chunk := range allChunks {
-			bufferedCh <- chunk
-		}
-		close(bufferedCh)
-
-		if playErr := sess.PlayPCMStream(ctx, bufferedCh); playErr != nil {
-			if ctx.Err() != nil {
-				logger.Info("text-only enunciate cancelled during TTS playback")
-				metrics.ActionsTotal.WithLabelValues("cancelled").Inc()
-				return
-			}
-			logger.Warn("TTS playback error", zap.Error(playErr))
-			metrics.ActionsTotal.WithLabelValues("tts_error").Inc()
+		ttsFirstChunkMs, ok := gw.speak(ctx, sess, sessionID, actionID, ttsText, ttsLang, cmd.TTSOptions, logger)
+		if !ok {
 			return
 		}
 
-		select {
-		case err := <-errs:
-			if err != nil {
-				logger.Warn("TTS stream error", zap.Error(err))
-			}
-		default:
-		}
-
-		ttsDuration := time.Since(ttsStart)
-		ttsDonePayload, _ := json.Marshal(datachannel.EventTtsDone{
-			DurationMs: int(ttsDuration.Milliseconds()),
-		})
-		sess.SendDataChannelMessage(datachannel.Envelope{
-			Type:      "tts.done",
-			SessionID: sessionID,
-			ActionID:  actionID,
-			Timestamp: time.Now().UnixMilli(),
-			Payload:   json.RawMessage(ttsDonePayload),
-		})
-
 		// Latency metrics
 		totalMs := float64(time.Since(start).Milliseconds())
 		latencyEvt := datachannel.EventMetricsLatency{
@@ -592,13 +826,58 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 		metrics.InferenceSemUsed.Dec()
 	}()
 
-	// 3. Snapshot ring buffer (pooled)
+	// 3. Snapshot ring buffer (pooled), trimmed to detected speech
 	snapshotStart := time.Now()
 	bufPtr := gw.snapshotPool.Get().(*[]byte)
-	pcm := sess.RingBuffer.SnapshotInto(lookback, *bufPtr)
+	var pcm []byte
+	var snapStart int
+	if cmd.BookmarkID != "" {
+		// Bookmark: lookback seconds forward from the bookmarked moment, exact.
+		bm, found := sess.Bookmark(cmd.BookmarkID)
+		if !found {
+			gw.snapshotPool.Put(bufPtr)
+			gw.sendError(sess, sessionID, actionID, "BOOKMARK_NOT_FOUND",
+				"unknown bookmark, or its audio has been overwritten")
+			metrics.ActionsTotal.WithLabelValues("range_unavailable").Inc()
+			return
+		}
+		var err error
+		snapStart = bm.Offset
+		end := min(bm.Offset+lookback*ringbuffer.BytesPerSecond, sess.RingBuffer.Written())
+		pcm, err = sess.RingBuffer.SnapshotRange(snapStart, end, *bufPtr)
+		if err != nil {
+			gw.snapshotPool.Put(bufPtr)
+			gw.sendRangeError(sess, sessionID, actionID, err)
+			metrics.ActionsTotal.WithLabelValues("range_unavailable").Inc()
+			return
+		}
+	} else if cmd.FromMs != nil {
+		// Explicit range: exact, untrimmed, and an error if no longer buffered.
+		var err error
+		snapStart = ringbuffer.OffsetForMs(*cmd.FromMs)
+		pcm, err = sess.RingBuffer.SnapshotRange(snapStart, ringbuffer.OffsetForMs(*cmd.ToMs), *bufPtr)
+		if err != nil {
+			gw.snapshotPool.Put(bufPtr)
+			gw.sendRangeError(sess, sessionID, actionID, err)
+			metrics.ActionsTotal.WithLabelValues("range_unavailable").Inc()
+			return
+		}
+	} else {
+		start, end, ok := gw.snapshotRange(sess, cmd.Utterances, lookback)
+		if !ok {
+			gw.snapshotPool.Put(bufPtr)
+			gw.sendError(sess, sessionID, actionID, "NO_SPEECH", "no speech detected in buffered audio")
+			metrics.ActionsTotal.WithLabelValues("no_speech").Inc()
+			return
+		}
+		pcm, snapStart = sess.RingBuffer.ReadSince(start, (*bufPtr)[:end-start])
+	}
+	// Captured now: the audio may be overwritten while ASR runs.
+	timeline := sess.RingBuffer.Timeline(snapStart, snapStart+len(pcm))
 	snapshotMs := float64(time.Since(snapshotStart).Microseconds()) / 1000.0
 	logger.Info("snapshot taken",
 		zap.Int("lookback", lookback),
+		zap.Int("utterances", cmd.Utterances),
 		zap.Int("bytes", len(pcm)),
 		zap.Float64("snapshotMs", snapshotMs),
 	)
@@ -608,6 +887,7 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 		gw.sendError(sess, sessionID, actionID, "INSUFFICIENT_AUDIO_BUFFER", "ring buffer empty")
 		return
 	}
+	prep, prepared := gw.preprocessForASR(sess, pcm, logger)
 
 	// 4. Determine ASR parameters
 	// Always transcribe — NLLB handles translation, not Whisper.
@@ -617,7 +897,9 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 
 	// 5. Call ASR (+ optional translation via NLLB inside ASR service)
 	asrStart := time.Now()
-	asrResp, err := gw.inferenceClient.Transcribe(ctx, pcm, sessionID, actionID, languageHint, task, targetLanguage)
+	// Stream partial hypotheses to the client as asr.partial while decoding runs.
+	asrResp, err := gw.inferenceClient.TranscribeStream(ctx, pcm, sessionID, actionID, languageHint, task, targetLanguage,
+		sendPartials(sess, sessionID, actionID))
 	// Return snapshot buffer to pool after ASR completes (pcm shares backing array)
 	gw.snapshotPool.Put(bufPtr)
 
@@ -628,7 +910,7 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 			metrics.ActionsTotal.WithLabelValues("timeout").Inc()
 		} else if ctx.Err() != nil {
 			logger.Info("enunciate cancelled during ASR")
-			metrics.ActionsTotal.WithLabelValues("cancelled").Inc()
+			metrics.ActionsTotal.WithLabelValues(cancelledOutcome(ctx)).Inc()
 		} else {
 			logger.Error("ASR failed", zap.Error(err))
 			gw.sendError(sess, sessionID, actionID, "ASR_FAILED", err.Error())
@@ -647,10 +929,12 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 	segments := make([]datachannel.Segment, 0, len(asrResp.Segments))
 	for _, s := range asrResp.Segments {
 		segments = append(segments, datachannel.Segment{
-			Text:       s.Text,
-			StartTime:  float64(s.StartTime),
-			EndTime:    float64(s.EndTime),
-			Confidence: float64(s.Confidence),
+			Text:           s.Text,
+			StartTime:      float64(s.StartTime),
+			EndTime:        float64(s.EndTime),
+			Confidence:     float64(s.Confidence),
+			StartTimestamp: snapshotTimestamp(timeline, snapStart, float64(s.StartTime)),
+			EndTimestamp:   snapshotTimestamp(timeline, snapStart, float64(s.EndTime)),
 		})
 	}
 	asrPayload, _ := json.Marshal(datachannel.EventAsrFinal{
@@ -661,6 +945,9 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 		Segments:       segments,
 		InferenceMs:    int(asrResp.InferenceDurationMs),
 		TranslateMs:    int(asrResp.TranslateDurationMs),
+		StartTimestamp: unixMilli(timeline.TimeAt(snapStart)),
+		EndTimestamp:   unixMilli(timeline.TimeAt(snapStart + len(pcm))),
+		Preprocessing:  asrPreprocessingEvent(prep, prepared),
 	})
 	sess.SendDataChannelMessage(datachannel.Envelope{
 		Type:      "asr.final",
@@ -682,117 +969,11 @@ func (gw *Gateway) executeEnunciate(ctx context.Context, sess *session.Session,
 			ttsLang = asrResp.TargetLanguage
 		}
 
-		// Start TTS streaming
-		ttsStart := time.Now()
-		voice := cmd.TTSOptions.Voice
-		if voice == "" {
-			voice = "default"
-		}
-		speed := float32(cmd.TTSOptions.Speed)
-		if speed <= 0 {
-			speed = 1.0
-		}
-
-		rawChunks, errs := gw.inferenceClient.SynthesizeStream(ctx, ttsText,
-			sessionID, actionID, voice, ttsLang, speed)
-
-		// Buffer all TTS chunks so we can calculate word marks
-		// before starting playback.
-		var allChunks [][]byte
-		var totalPCMBytes int
-		first := true
-		for chunk := range rawChunks {
-			if first {
-				ttsFirstChunkMs = float64(time.Since(ttsStart).Microseconds()) / 1000.0
-				first = false
-			}
-			allChunks = append(allChunks, chunk)
-			totalPCMBytes += len(chunk)
-			if ctx.Err() != nil {
-				break
-			}
-		}
-
-		// Calculate word marks and send tts.marks event before playback.
-		// 16kHz * 2 bytes/sample = 32 bytes per ms.
-		if totalPCMBytes > 0 {
-			totalDurationMs := float64(totalPCMBytes) / 32.0
-			marks := calculateWordMarks(ttsText, totalDurationMs)
-			marksPayload, _ := json.Marshal(datachannel.EventTtsMarks{
-				Text:       ttsText,
-				Words:      marks,
-				DurationMs: totalDurationMs,
-			})
-			sess.SendDataChannelMessage(datachannel.Envelope{
-				Type:      "tts.marks",
-				SessionID: sessionID,
-				ActionID:  actionID,
-				Timestamp: time.Now().UnixMilli(),
-				Payload:   json.RawMessage(marksPayload),
-			})
-		}
-
-		// Send tts.started AFTER marks so the client has word data ready
-		// when it begins scheduling highlight timers.
-		sess.SendDataChannelMessage(datachannel.Envelope{
-			Type:      "tts.started",
-			SessionID: sessionID,
-			ActionID:  actionID,
-			Timestamp: time.Now().UnixMilli(),
-			Payload:   json.RawMessage(`{"voice":"default"}`),
-		})
-
-		// Feed buffered chunks to PlayPCMStream.
-		bufferedCh := make(chan []byte, len(allChunks))
-		for _, chunk := range allChunks {
-			bufferedCh <- chunk
-		}
-		close(bufferedCh)
-
-		logger.Info("starting TTS playback",
-			zap.Int("chunks", len(allChunks)),
-			zap.Int("totalPCMBytes", totalPCMBytes),
-		)
-
-		// Play audio stream to browser
-		if playErr := sess.PlayPCMStream(ctx, bufferedCh); playErr != nil {
-			if ctx.Err() == context.DeadlineExceeded {
-				logger.Warn("enunciate timed out during TTS playback")
-				metrics.InferenceTimeoutsTotal.Inc()
-				metrics.ActionsTotal.WithLabelValues("timeout").Inc()
-				return
-			}
-			if ctx.Err() != nil {
-				logger.Info("enunciate cancelled during TTS playback")
-				metrics.ActionsTotal.WithLabelValues("cancelled").Inc()
-				return
-			}
-			logger.Warn("TTS playback error", zap.Error(playErr))
-			metrics.ActionsTotal.WithLabelValues("tts_error").Inc()
+		var ok bool
+		ttsFirstChunkMs, ok = gw.speak(ctx, sess, sessionID, actionID, ttsText, ttsLang, cmd.TTSOptions, logger)
+		if !ok {
 			return
 		}
-
-		// Check for gRPC errors
-		select {
-		case err := <-errs:
-			if err != nil {
-				logger.Warn("TTS stream error", zap.Error(err))
-			}
-		default:
-		}
-
-		// Send tts.done event
-		ttsDuration := time.Since(ttsStart)
-		ttsDonePayload, _ := json.Marshal(datachannel.EventTtsDone{
-			DurationMs: int(ttsDuration.Milliseconds()),
-		})
-		sess.SendDataChannelMessage(datachannel.Envelope{
-			Type:      "tts.done",
-			SessionID: sessionID,
-			ActionID:  actionID,
-			Timestamp: time.Now().UnixMilli(),
-			Payload:   json.RawMessage(ttsDonePayload),
-		})
 	}
 
 	// 7. Send latency metrics + record Prometheus histograms
@@ -868,6 +1049,120 @@ func calculateWordMarks(text string, totalDurationMs float64) []datachannel.Word
 	return marks
 }
 
+// snapshotTimestamp maps a time in seconds into a snapshot starting at the absolute
+// offset snapStart to its wall-clock capture time in Unix ms.
+func snapshotTimestamp(timeline ringbuffer.Timeline, snapStart int, sec float64) int64 {
+	return unixMilli(timeline.TimeAt(snapStart + ringbuffer.OffsetForMs(int(sec*1000))))
+}
+
+// validateEnunciateRange checks the fromMs/toMs range of an enunciate command.
+// Returns an error message, or "" if the command is valid.
+func (gw *Gateway) validateEnunciateRange(cmd datachannel.CommandEnunciate) string {
+	if cmd.FromMs == nil && cmd.ToMs == nil {
+		return ""
+	}
+	if cmd.BookmarkID != "" {
+		return "bookmarkId cannot be combined with fromMs/toMs"
+	}
+	if cmd.FromMs == nil || cmd.ToMs == nil {
+		return "fromMs and toMs must be set together"
+	}
+	if *cmd.FromMs < 0 || *cmd.ToMs <= *cmd.FromMs {
+		return "toMs must be greater than fromMs, and fromMs not negative"
+	}
+	if maxMs := gw.cfg.MaxLookbackSec * 1000; *cmd.ToMs-*cmd.FromMs > maxMs {
+		return fmt.Sprintf("range exceeds the maximum of %dms", maxMs)
+	}
+	return ""
+}
+
+// sendRangeError reports a fromMs/toMs range that cannot be read from the
+// ring buffer, including the currently buffered range so the client can retry.
+func (gw *Gateway) sendRangeError(sess *session.Session, sessionID, actionID string, err error) {
+	oldestMs := streamOffsetMs(sess.RingBuffer.Oldest())
+	latestMs := streamOffsetMs(sess.RingBuffer.Written())
+	switch {
+	case errors.Is(err, ringbuffer.ErrRangeOverwritten):
+		gw.sendError(sess, sessionID, actionID, "RANGE_OVERWRITTEN",
+			fmt.Sprintf("requested range has been overwritten; buffered audio spans %.0f-%.0fms", oldestMs, latestMs))
+	case errors.Is(err, ringbuffer.ErrRangeNotWritten):
+		gw.sendError(sess, sessionID, actionID, "RANGE_NOT_BUFFERED",
+			fmt.Sprintf("requested range extends past buffered audio; buffered audio spans %.0f-%.0fms", oldestMs, latestMs))
+	case errors.Is(err, ringbuffer.ErrInvalidRange):
+		gw.sendError(sess, sessionID, actionID, "INVALID_COMMAND", err.Error())
+	default:
+		// The disk tier failed to read; the request itself was fine.
+		gw.logger.Error("buffered audio read failed", zap.String("session", sessionID), zap.Error(err))
+		gw.sendError(sess, sessionID, actionID, "INTERNAL_ERROR", "failed to read buffered audio")
+	}
+}
+
+// preprocessForASR conditions a snapshot in place with the session's ASR
+// preprocessing options. ok is false when preprocessing is disabled.
+func (gw *Gateway) preprocessForASR(sess *session.Session, pcm []byte, logger *zap.Logger) (res audio.PreprocessResult, ok bool) {
+	opts := sess.ASRPreprocessing()
+	if !opts.Normalize && !opts.HighPass {
+		return res, false
+	}
+	res = audio.Preprocess(pcm, audio.PCMSampleRate, opts)
+	logger.Debug("snapshot preprocessed",
+		zap.Float64("loudnessLufs", res.LoudnessLUFS),
+		zap.Bool("measured", res.Measured),
+		zap.Float64("gainDb", res.GainDB),
+		zap.Int("limitedSamples", res.LimitedSamples),
+	)
+	return res, true
+}
+
+// asrPreprocessingEvent reports preprocessing in asr.final, or nil if none ran.
+func asrPreprocessingEvent(res audio.PreprocessResult, ok bool) *datachannel.AsrPreprocessing {
+	if !ok {
+		return nil
+	}
+	ev := &datachannel.AsrPreprocessing{
+		GainDb:         math.Round(res.GainDB*100) / 100,
+		LimitedSamples: res.LimitedSamples,
+	}
+	if res.Measured {
+		lufs := math.Round(res.LoudnessLUFS*100) / 100
+		ev.InputLufs = &lufs
+	}
+	return ev
+}
+
+// snapshotRange picks the absolute ring buffer range to send to ASR.
+// With utterances > 0 it spans the last N speech regions; otherwise the last
+// lookback seconds, trimmed of leading/trailing silence when speech was detected
+// (audio the VAD never flags, e.g. music, is sent untrimmed).
+// ok is false only when utterances were requested and none are buffered.
+func (gw *Gateway) snapshotRange(sess *session.Session, utterances, lookback int) (start, end int, ok bool) {
+	rb := sess.RingBuffer
+	pad := speechPadMs * ringbuffer.BytesPerSecond / 1000
+	maxBytes := gw.cfg.MaxLookbackSec * ringbuffer.BytesPerSecond
+
+	written := rb.Written()
+	oldest := rb.Oldest()
+
+	if utterances > 0 {
+		regions := rb.Utterances(utterances)
+		if len(regions) == 0 {
+			return 0, 0, false
+		}
+		start = max(regions[0].Start-pad, oldest)
+		end = min(regions[len(regions)-1].End+pad, written)
+	} else {
+		end = written
+		start = max(end-lookback*ringbuffer.BytesPerSecond, oldest)
+		start, end, _ = rb.SpeechBounds(start, end, pad)
+	}
+
+	// Keep the most recent audio if the range outgrows a pooled snapshot buffer.
+	if end-start > maxBytes {
+		start = end - maxBytes
+	}
+	return start, end, true
+}
+
 // sendError sends an error event over the data channel.
 func (gw *Gateway) sendError(sess *session.Session, sessionID, actionID, code, message string) {
 	errPayload, _ := json.Marshal(datachannel.EventError{
@@ -882,3 +1177,21 @@ func (gw *Gateway) sendError(sess *session.Session, sessionID, actionID, code, m
 		Payload:   json.RawMessage(errPayload),
 	})
 }
+
+// sendPartials returns a PartialFunc that forwards each ASR hypothesis to the
+// client as an asr.partial event.
+func sendPartials(sess *session.Session, sessionID, actionID string) inference.PartialFunc {
+	return func(partial *whatsv1.TranscribePartial) {
+		partialPayload, _ := json.Marshal(datachannel.EventAsrPartial{
+			Text:     partial.Text,
+			Language: partial.Language,
+		})
+		sess.SendDataChannelMessage(datachannel.Envelope{
+			Type:      "asr.partial",
+			SessionID: sessionID,
+			ActionID:  actionID,
+			Timestamp: time.Now().UnixMilli(),
+			Payload:   json.RawMessage(partialPayload),
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	whatsv1 "github.com/RenatoCabral2022/WhatsWebService/gen/go/whats/v1"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/config"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/inference"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/session"
+)
+
+// payload decodes the payload of the last recorded event of the given type.
+func payload(t *testing.T, rec *recorder, typ string, v any) {
+	t.Helper()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	for i := len(rec.events) - 1; i >= 0; i-- {
+		if rec.events[i].Type == typ {
+			if err := json.Unmarshal(rec.events[i].Payload, v); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("no %s event in %v", typ, rec.events)
+}
+
+func TestUpdateSessionStreamingDefault(t *testing.T) {
+	gw := newTestGateway(nil)
+	sess := newTestSession(t)
+	update := gw.makeUpdateHandler(sess.Session)
+
+	update("sess-1", "", json.RawMessage(`{"ttsOptions":{"streaming":true,"voice":"nova"}}`))
+	if opts := withTTSDefaults(datachannel.TTSOptions{}, sess.Defaults().TTSOptions); !opts.IsStreaming() || opts.Voice != "nova" {
+		t.Fatalf("enunciate options = %+v, want streaming with voice nova", opts)
+	}
+
+	// streaming:false clears the default; omitting it leaves it alone.
+	update("sess-1", "", json.RawMessage(`{"ttsOptions":{"streaming":false}}`))
+	update("sess-1", "", json.RawMessage(`{"ttsOptions":{"speed":1.5}}`))
+	var applied datachannel.EventUpdateApplied
+	payload(t, sess.rec, "update.applied", &applied)
+	if applied.TTSOptions.IsStreaming() || applied.TTSOptions.Streaming == nil || applied.TTSOptions.Voice != "nova" {
+		t.Errorf("update.applied = %+v", applied)
+	}
+}
+
+func TestUpdateActionBeforeAndAfterSynthesis(t *testing.T) {
+	gw := newTestGateway(nil)
+	sess := newTestSession(t)
+	update := gw.makeUpdateHandler(sess.Session)
+
+	sess.TryStartAction("a1", time.Minute)
+	sess.SetActionTTSOptions("a1", datachannel.TTSOptions{Voice: "alloy"})
+
+	// Before synthesis the voice is taken.
+	update("sess-1", "a1", json.RawMessage(`{"ttsOptions":{"voice":"nova"}}`))
+	if opts := sess.BeginSynthesis("a1", datachannel.TTSOptions{}); opts.Voice != "nova" {
+		t.Fatalf("synthesis voice = %q, want nova", opts.Voice)
+	}
+
+	// After, a voice change is reported as not applied; speed still applies.
+	update("sess-1", "a1", json.RawMessage(`{"ttsOptions":{"voice":"echo","speed":1.5}}`))
+	var applied datachannel.EventUpdateApplied
+	payload(t, sess.rec, "update.applied", &applied)
+	if applied.Scope != "action" || applied.TTSOptions.Voice != "nova" || applied.TTSOptions.Speed != 1.5 ||
+		!slices.Equal(applied.NotApplied, []string{"voice"}) {
+		t.Errorf("update.applied = %+v", applied)
+	}
+
+	update("sess-1", "other", json.RawMessage(`{"ttsOptions":{"speed":1.5}}`))
+	var errEvt datachannel.EventError
+	payload(t, sess.rec, "error", &errEvt)
+	if errEvt.Code != "ACTION_NOT_ACTIVE" {
+		t.Errorf("error = %+v", errEvt)
+	}
+}
+
+func TestUpdateSpeedReschedulesMarks(t *testing.T) {
+	gw := newTestGateway(nil)
+	sess := newTestSession(t)
+	sess.TryStartAction("a1", time.Minute)
+	sess.BeginSynthesis("a1", datachannel.TTSOptions{})
+	gw.sendWordMarks(sess, "sess-1", "a1", "tts.marks", "one two", 1000*time.Millisecond)
+
+	// Doubling the rate before playback starts halves every mark.
+	gw.makeUpdateHandler(sess.Session)("sess-1", "a1", json.RawMessage(`{"ttsOptions":{"speed":2}}`))
+	if got := sess.rec.types(); !slices.Equal(got, []string{"tts.marks", "update.applied", "tts.marks.update"}) {
+		t.Fatalf("events = %v", got)
+	}
+	var marks datachannel.EventTtsMarks
+	payload(t, sess.rec, "tts.marks.update", &marks)
+	if marks.DurationMs != 500 || marks.Words[1].EndMs != 500 || marks.Words[1].StartMs != 250 {
+		t.Errorf("marks = %+v", marks)
+	}
+}
+
+func TestCancelDuringSynthesis(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{TTSChunkCount: 2, TTSChunkDelay: time.Hour})
+	sess := newTestSession(t)
+	ctx := sess.TryStartAction("a1", time.Minute)
+
+	done := make(chan bool)
+	go func() {
+		_, ok := gw.speak(ctx, sess, "sess-1", "a1", "hello", "en", datachannel.TTSOptions{}, zap.NewNop())
+		done <- ok
+	}()
+	gw.makeCancelHandler(sess.Session)("sess-1", "a1", nil)
+
+	if ok := <-done; ok {
+		t.Fatal("speak completed after cancel")
+	}
+	if got := cancelledOutcome(ctx); got != "user_cancelled" {
+		t.Errorf("outcome = %q, want user_cancelled", got)
+	}
+	var cancelled datachannel.EventActionCancelled
+	payload(t, sess.rec, "action.cancelled", &cancelled)
+	if cancelled.Reason != "client" || slices.Contains(sess.rec.types(), "tts.done") {
+		t.Errorf("events = %v", sess.rec.types())
+	}
+}
+
+func TestCancelDuringPlayback(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{TTSChunkCount: 3})
+	sess := newTestSession(t)
+	sess.playing, sess.hold = make(chan struct{}), true
+	playing := sess.playing
+	ctx := sess.TryStartAction("a1", time.Minute)
+
+	done := make(chan bool)
+	go func() {
+		_, ok := gw.speak(ctx, sess, "sess-1", "a1", "hello", "en", datachannel.TTSOptions{}, zap.NewNop())
+		done <- ok
+	}()
+	<-playing
+	// An empty actionId cancels whatever is in flight.
+	cancel := gw.makeCancelHandler(sess.Session)
+	cancel("sess-1", "", nil)
+
+	if ok := <-done; ok {
+		t.Fatal("speak completed after cancel")
+	}
+	if got := cancelledOutcome(ctx); got != "user_cancelled" {
+		t.Errorf("outcome = %q, want user_cancelled", got)
+	}
+	got := sess.rec.types()
+	if i := slices.Index(got, "action.cancelled"); i < slices.Index(got, "play") || slices.Contains(got, "tts.done") {
+		t.Errorf("events = %v", got)
+	}
+	var cancelled datachannel.EventActionCancelled
+	payload(t, sess.rec, "action.cancelled", &cancelled)
+
+	// Cancelling again while the action winds down is an error, not a second
+	// action.cancelled.
+	cancel("sess-1", "a1", nil)
+	var errEvt datachannel.EventError
+	payload(t, sess.rec, "error", &errEvt)
+	if errEvt.Code != "ACTION_NOT_ACTIVE" || countEvents(sess.rec, "action.cancelled") != 1 {
+		t.Errorf("events after second cancel = %v", sess.rec.types())
+	}
+}
+
+func TestCancelUnknownAction(t *testing.T) {
+	gw := newTestGateway(nil)
+	sess := newTestSession(t)
+	cancel := gw.makeCancelHandler(sess.Session)
+
+	cancel("sess-1", "", nil) // nothing in flight
+	ctx := sess.TryStartAction("a1", time.Minute)
+	cancel("sess-1", "a2", nil)
+
+	if n := countEvents(sess.rec, "error"); n != 2 || countEvents(sess.rec, "action.cancelled") != 0 {
+		t.Errorf("events = %v", sess.rec.types())
+	}
+	if ctx.Err() != nil {
+		t.Error("cancelling another actionId cancelled the active action")
+	}
+
+	// A superseded action is cancelled, but not by the user.
+	sess.TryStartAction("a3", time.Minute)
+	if got := cancelledOutcome(ctx); got != "cancelled" {
+		t.Errorf("outcome = %q, want cancelled", got)
+	}
+}
+
+func countEvents(rec *recorder, typ string) int {
+	n := 0
+	for _, got := range rec.types() {
+		if got == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestForwardASRPartials(t *testing.T) {
+	client := &inference.MockClient{TranscribeText: "hello there world", TranscribeDelay: 4 * time.Millisecond}
+	sess := newTestSession(t)
+
+	resp, err := client.TranscribeStream(context.Background(), nil, "sess-1", "a1", "", "transcribe", "",
+		sendPartials(sess.Session, "sess-1", "a1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sess.rec.types(); !slices.Equal(got, []string{"asr.partial", "asr.partial", "asr.partial"}) {
+		t.Fatalf("events = %v", got)
+	}
+	var partial datachannel.EventAsrPartial
+	payload(t, sess.rec, "asr.partial", &partial)
+	if partial.Text != resp.Text || partial.Language != "en" {
+		t.Errorf("last partial = %+v, want the final text %q", partial, resp.Text)
+	}
+	if e, _ := sess.rec.event("asr.partial"); e.ActionID != "a1" || e.SessionID != "sess-1" {
+		t.Errorf("partial envelope = %+v", e)
+	}
+}
+
+// newASRTestGateway returns a gateway set up to snapshot up to 5s of audio
+// for ASR, captioning in 1s windows.
+func newASRTestGateway(client inference.InferenceClient) *Gateway {
+	gw := newTestGateway(client)
+	gw.cfg = &config.Config{CaptionWindowSec: 1, MaxLookbackSec: 5}
+	gw.inferenceSem = make(chan struct{}, 1)
+	gw.snapshotPool = sync.Pool{New: func() interface{} {
+		buf := make([]byte, 5*ringbuffer.BytesPerSecond)
+		return &buf
+	}}
+	return gw
+}
+
+// snapshotClient records the audio sent to ASR.
+type snapshotClient struct {
+	inference.MockClient
+	audio []byte
+}
+
+func (c *snapshotClient) TranscribeStream(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string, onPartial inference.PartialFunc) (*whatsv1.TranscribeResponse, error) {
+	c.audio = append([]byte(nil), audio...)
+	return c.MockClient.TranscribeStream(ctx, audio, sessionID, actionID, languageHint, task, targetLanguage, onPartial)
+}
+
+func TestEnunciateFromBookmark(t *testing.T) {
+	client := &snapshotClient{}
+	gw := newASRTestGateway(client)
+	sess := newTestSession(t)
+	sess.SetASRPreprocessing(audio.PreprocessOptions{}) // compare the audio as written
+	t0 := time.Unix(1700000000, 0)
+	for i := 0; i < 4; i++ {
+		// Each second of audio is filled with its index.
+		second := bytes.Repeat([]byte{byte(i)}, ringbuffer.BytesPerSecond)
+		sess.RingBuffer.WriteAt(second, t0.Add(time.Duration(i)*time.Second))
+		if i == 0 {
+			sess.AddBookmark("b1", "")
+		}
+	}
+
+	// Two seconds from the bookmark: the second and third seconds written.
+	cmd := datachannel.CommandEnunciate{BookmarkID: "b1", LookbackSeconds: 2}
+	gw.executeEnunciate(sess.TryStartAction("a1", time.Minute), sess.Session, "sess-1", "a1", cmd)
+
+	want := append(bytes.Repeat([]byte{1}, ringbuffer.BytesPerSecond), bytes.Repeat([]byte{2}, ringbuffer.BytesPerSecond)...)
+	if !bytes.Equal(client.audio, want) {
+		t.Errorf("transcribed %d bytes from the wrong range", len(client.audio))
+	}
+	var final datachannel.EventAsrFinal
+	payload(t, sess.rec, "asr.final", &final)
+	if final.StartTimestamp != t0.Add(time.Second).UnixMilli() || final.EndTimestamp != t0.Add(3*time.Second).UnixMilli() {
+		t.Errorf("asr.final range = %d..%d", final.StartTimestamp, final.EndTimestamp)
+	}
+}
+
+func TestEnunciateFromMissingBookmark(t *testing.T) {
+	gw := newASRTestGateway(&inference.MockClient{})
+	sess := newTestSession(t)
+	second := make([]byte, ringbuffer.BytesPerSecond)
+	sess.RingBuffer.Write(second)
+	sess.AddBookmark("old", "")
+	// Overwrite the bookmarked audio in the 5s ring buffer.
+	for i := 0; i < 6; i++ {
+		sess.RingBuffer.Write(second)
+	}
+
+	for _, id := range []string{"old", "unknown"} {
+		cmd := datachannel.CommandEnunciate{BookmarkID: id}
+		gw.executeEnunciate(sess.TryStartAction(id, time.Minute), sess.Session, "sess-1", id, cmd)
+
+		var evt datachannel.EventError
+		payload(t, sess.rec, "error", &evt)
+		if evt.Code != "BOOKMARK_NOT_FOUND" {
+			t.Errorf("bookmark %q: error = %+v", id, evt)
+		}
+	}
+	if slices.Contains(sess.rec.types(), "asr.final") {
+		t.Error("transcribed without a bookmark")
+	}
+}
+
+func TestAttachDVRRejectsUnsafeSessionIDs(t *testing.T) {
+	root := t.TempDir()
+	dvrDir := filepath.Join(root, "dvr")
+	if err := os.Mkdir(dvrDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	gw := newTestGateway(&inference.MockClient{})
+	gw.cfg = &config.Config{DVRDir: dvrDir, DVRSec: 10, DVRSegmentSec: 1}
+
+	for _, id := range []string{"", ".", "..", "a/b", "../dvr", "a.b"} {
+		sess := session.New(id, 1, zap.NewNop())
+		gw.attachDVR(sess, zap.NewNop())
+		// Stopping removes the DVR directory of an attached tier.
+		sess.Stop()
+		if _, err := os.Stat(dvrDir); err != nil {
+			t.Fatalf("session %q: %v", id, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dvrDir); len(entries) != 0 {
+		t.Errorf("DVR directories created for unsafe IDs: %v", entries)
+	}
+
+	sess := session.New("3f2b9c1e-7d4a-4e8b-9c2d-1a5e6f7b8c9d", 1, zap.NewNop())
+	gw.attachDVR(sess, zap.NewNop())
+	if entries, _ := os.ReadDir(dvrDir); len(entries) != 1 {
+		t.Errorf("no DVR directory for a UUID session ID")
+	}
+	sess.Stop()
+}
+
+func TestRangeErrorCodes(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{})
+	sess := newTestSession(t)
+	for err, want := range map[error]string{
+		ringbuffer.ErrRangeOverwritten:                             "RANGE_OVERWRITTEN",
+		ringbuffer.ErrRangeNotWritten:                              "RANGE_NOT_BUFFERED",
+		ringbuffer.ErrInvalidRange:                                 "INVALID_COMMAND",
+		errors.New("ringbuffer: read segment: input/output error"): "INTERNAL_ERROR",
+	} {
+		gw.sendRangeError(sess.Session, "sess-1", "a1", err)
+		var evt datachannel.EventError
+		payload(t, sess.rec, "error", &evt)
+		if evt.Code != want {
+			t.Errorf("%v: code %s, want %s", err, evt.Code, want)
+		}
+	}
+}
+
+func TestAudioSnapshotDefaultRange(t *testing.T) {
+	// More buffered than can be exported at once, as with DVR enabled.
+	const id = `sess"1`
+	sess := session.New(id, maxSnapshotExportSec+100, zap.NewNop())
+	defer sess.Stop()
+	second := make([]byte, ringbuffer.BytesPerSecond)
+	for i := 0; i < maxSnapshotExportSec+100; i++ {
+		sess.RingBuffer.Write(second)
+	}
+	gw := newTestGateway(&inference.MockClient{})
+	gw.sessions[id] = sess
+
+	w := httptest.NewRecorder()
+	gw.handleAudioSnapshot(w, httptest.NewRequest("GET", "/audio/snapshot", nil), id)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	// The most recent maxSnapshotExportSec, as a WAV file.
+	if want := 44 + maxSnapshotExportSec*ringbuffer.BytesPerSecond; w.Body.Len() != want {
+		t.Errorf("%d bytes, want %d", w.Body.Len(), want)
+	}
+	_, params, err := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+	if want := `sess"1-100000-700000.wav`; err != nil || params["filename"] != want {
+		t.Errorf("Content-Disposition %q, want filename %q", w.Header().Get("Content-Disposition"), want)
+	}
+
+	// An explicit start still can't exceed the limit.
+	w = httptest.NewRecorder()
+	gw.handleAudioSnapshot(w, httptest.NewRequest("GET", "/audio/snapshot?fromMs=0", nil), id)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("fromMs=0: status %d, want 400", w.Code)
+	}
+}
@@ -4,21 +4,110 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ingest"
 	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/metrics"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
 )
 
 type createSessionRequest struct {
-	SessionID string `json:"sessionId"`
+	SessionID        string                   `json:"sessionId"`
+	EncoderProfile   *encoderProfileRequest   `json:"encoderProfile,omitempty"`
+	ASRPreprocessing *asrPreprocessingRequest `json:"asrPreprocessing,omitempty"`
+}
+
+// encoderProfileRequest selects the outbound Opus encoder profile. Fields left
+// unset take their value from the preset ("voice" by default).
+type encoderProfileRequest struct {
+	Preset      string `json:"preset,omitempty"`
+	Application string `json:"application,omitempty"`
+	Bitrate     *int   `json:"bitrate,omitempty"`
+	Complexity  *int   `json:"complexity,omitempty"`
+	FEC         *bool  `json:"fec,omitempty"`
+	DTX         *bool  `json:"dtx,omitempty"`
+	DualMono    *bool  `json:"dualMono,omitempty"`
+}
+
+// asrPreprocessingRequest overrides the gateway's ASR preprocessing defaults.
+type asrPreprocessingRequest struct {
+	Normalize  *bool    `json:"normalize,omitempty"`
+	TargetLUFS *float64 `json:"targetLufs,omitempty"`
+	MaxGainDB  *float64 `json:"maxGainDb,omitempty"`
+	HighPass   *bool    `json:"highPass,omitempty"`
+}
+
+// resolve applies the request's overrides to defaults and validates the result.
+func (r *asrPreprocessingRequest) resolve(defaults audio.PreprocessOptions) (audio.PreprocessOptions, error) {
+	opts := defaults
+	if r == nil {
+		return opts, nil
+	}
+	if r.Normalize != nil {
+		opts.Normalize = *r.Normalize
+	}
+	if r.TargetLUFS != nil {
+		opts.TargetLUFS = *r.TargetLUFS
+	}
+	if r.MaxGainDB != nil {
+		opts.MaxGainDB = *r.MaxGainDB
+	}
+	if r.HighPass != nil {
+		opts.HighPass = *r.HighPass
+	}
+	if opts.TargetLUFS < -40 || opts.TargetLUFS > -5 {
+		return opts, fmt.Errorf("targetLufs must be between -40 and -5")
+	}
+	if opts.MaxGainDB < 0 || opts.MaxGainDB > 40 {
+		return opts, fmt.Errorf("maxGainDb must be between 0 and 40")
+	}
+	return opts, nil
+}
+
+// resolve applies the request's overrides to its preset and validates the result.
+func (r *encoderProfileRequest) resolve() (audio.EncoderProfile, error) {
+	if r == nil {
+		return audio.VoiceProfile, nil
+	}
+	preset := r.Preset
+	if preset == "" {
+		preset = "voice"
+	}
+	p, ok := audio.ProfileByName(preset)
+	if !ok {
+		return audio.EncoderProfile{}, fmt.Errorf("unknown preset %q", preset)
+	}
+	if r.Application != "" {
+		p.Application = r.Application
+	}
+	if r.Bitrate != nil {
+		p.Bitrate = *r.Bitrate
+	}
+	if r.Complexity != nil {
+		p.Complexity = *r.Complexity
+	}
+	if r.FEC != nil {
+		p.FEC = *r.FEC
+	}
+	if r.DTX != nil {
+		p.DTX = *r.DTX
+	}
+	if r.DualMono != nil {
+		p.DualMono = *r.DualMono
+	}
+	return p, p.Validate()
 }
 
 type createSessionResponse struct {
@@ -35,15 +124,71 @@ type answerRequest struct {
 }
 
 type ingestStartRequest struct {
-	URL string `json:"url"`
+	// Type picks a registered source (url, hls, file, s3, tone); when empty
+	// it follows from the URL scheme.
+	Type string `json:"type,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// Relay plays the ingested audio to the client, ducked under TTS.
+	Relay bool `json:"relay,omitempty"`
+	// HLS selects a rendition; it also forces HLS handling for URLs that do
+	// not end in .m3u8.
+	HLS *hlsOptionsRequest `json:"hls,omitempty"`
+	// Reconnect overrides the gateway's reconnect policy for dropped streams.
+	Reconnect *reconnectRequest `json:"reconnect,omitempty"`
+}
+
+type reconnectRequest struct {
+	MaxAttempts      *int     `json:"maxAttempts,omitempty"`
+	InitialBackoffMs *int     `json:"initialBackoffMs,omitempty"`
+	MaxBackoffMs     *int     `json:"maxBackoffMs,omitempty"`
+	Jitter           *float64 `json:"jitter,omitempty"`
+}
+
+// resolve applies the request's overrides to defaults and validates the result.
+func (r *reconnectRequest) resolve(defaults ingest.ReconnectPolicy) (ingest.ReconnectPolicy, error) {
+	p := defaults
+	if r == nil {
+		return p, nil
+	}
+	if r.MaxAttempts != nil {
+		p.MaxAttempts = *r.MaxAttempts
+	}
+	if r.InitialBackoffMs != nil {
+		p.InitialBackoff = time.Duration(*r.InitialBackoffMs) * time.Millisecond
+	}
+	if r.MaxBackoffMs != nil {
+		p.MaxBackoff = time.Duration(*r.MaxBackoffMs) * time.Millisecond
+	}
+	if r.Jitter != nil {
+		p.Jitter = *r.Jitter
+	}
+	return p, p.Validate()
+}
+
+type hlsOptionsRequest struct {
+	Language     string `json:"language,omitempty"`
+	MaxBandwidth int    `json:"maxBandwidth,omitempty"`
 }
 
 type ingestStatusResponse struct {
-	State           string  `json:"state"`
-	SourceURL       string  `json:"sourceUrl"`
-	SecondsBuffered float64 `json:"secondsBuffered"`
-	BytesRead       int64   `json:"bytesRead"`
-	LastError       string  `json:"lastError,omitempty"`
+	Type            string               `json:"type,omitempty"`
+	State           string               `json:"state"`
+	SourceURL       string               `json:"sourceUrl"`
+	SecondsBuffered float64              `json:"secondsBuffered"`
+	BytesRead       int64                `json:"bytesRead"`
+	LastError       string               `json:"lastError,omitempty"`
+	Relaying        bool                 `json:"relaying,omitempty"`
+	SegmentsFetched int64                `json:"segmentsFetched,omitempty"`
+	LiveLatencySec  float64              `json:"liveLatencySec,omitempty"`
+	Reconnects      int                  `json:"reconnects,omitempty"`
+	ErrorHistory    []ingest.ErrorRecord `json:"errorHistory,omitempty"`
+	Metadata        *ingest.Metadata     `json:"metadata,omitempty"`
+	Seekable        bool                 `json:"seekable,omitempty"`
+	PositionSec     float64              `json:"positionSec,omitempty"`
+}
+
+type ingestSeekRequest struct {
+	PositionSec *float64 `json:"positionSec"`
 }
 
 // InternalHandler returns an http.Handler for the gateway's internal API.
@@ -81,7 +226,21 @@ func (gw *Gateway) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sdpOffer, err := gw.CreateSession(req.SessionID)
+	opts := gw.DefaultSessionOptions()
+	if opts.Encoder, err = req.EncoderProfile.resolve(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid encoderProfile: " + err.Error()})
+		return
+	}
+	if opts.ASRPreprocessing, err = req.ASRPreprocessing.resolve(opts.ASRPreprocessing); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid asrPreprocessing: " + err.Error()})
+		return
+	}
+
+	sdpOffer, err := gw.CreateSession(req.SessionID, opts)
 	if err != nil {
 		gw.logger.Error("create session failed", zap.Error(err))
 		http.Error(w, "create session failed", http.StatusInternalServerError)
@@ -147,6 +306,18 @@ func (gw *Gateway) handleSessionRoutes(w http.ResponseWriter, r *http.Request) {
 		gw.handleIngestStatus(w, r, sessionID)
 		return
 	}
+	if suffix == "ingest/pause" && r.Method == http.MethodPost {
+		gw.handleIngestControl(w, sessionID, ingest.Seekable.Pause)
+		return
+	}
+	if suffix == "ingest/resume" && r.Method == http.MethodPost {
+		gw.handleIngestControl(w, sessionID, ingest.Seekable.Resume)
+		return
+	}
+	if suffix == "ingest/seek" && r.Method == http.MethodPost {
+		gw.handleIngestSeek(w, r, sessionID)
+		return
+	}
 
 	// Audio upload route (binary MP3 → decode → ring buffer)
 	if suffix == "audio/upload" && r.Method == http.MethodPost {
@@ -154,6 +325,12 @@ func (gw *Gateway) handleSessionRoutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Audio snapshot export (ring buffer → WAV / Ogg Opus)
+	if suffix == "audio/snapshot" && r.Method == http.MethodGet {
+		gw.handleAudioSnapshot(w, r, sessionID)
+		return
+	}
+
 	http.Error(w, "not found", http.StatusNotFound)
 }
 
@@ -189,15 +366,33 @@ func (gw *Gateway) handleIngestStart(w http.ResponseWriter, r *http.Request, ses
 		http.Error(w, "read body failed", http.StatusBadRequest)
 		return
 	}
-	if err := json.Unmarshal(body, &req); err != nil || req.URL == "" {
+	if err := json.Unmarshal(body, &req); err != nil || (req.URL == "" && req.Type == "") {
 		http.Error(w, `{"error":"url is required"}`, http.StatusBadRequest)
 		return
 	}
 	req.URL = strings.TrimSpace(req.URL)
 
-	// SSRF validation
-	if err := ingest.ValidateURL(req.URL); err != nil {
-		gw.logger.Warn("ingest URL rejected", zap.String("url", req.URL), zap.Error(err))
+	src := ingest.Request{Type: req.Type, URL: req.URL}
+	if req.HLS != nil {
+		src.HLS = ingest.HLSOptions{Language: req.HLS.Language, MaxBandwidth: req.HLS.MaxBandwidth}
+		if src.Type == "" {
+			src.Type = ingest.TypeHLS
+		}
+	}
+	policy, err := req.Reconnect.resolve(gw.reconnectPolicy())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "reconnect: " + err.Error()})
+		return
+	}
+
+	// Each source validates its own requests (SSRF checks for URLs, library
+	// containment for files, ...).
+	src, err = gw.ingestSources.Resolve(src)
+	if err != nil {
+		gw.logger.Warn("ingest source rejected", zap.String("type", src.Type),
+			zap.String("url", req.URL), zap.Error(err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -216,12 +411,58 @@ func (gw *Gateway) handleIngestStart(w http.ResponseWriter, r *http.Request, ses
 	sess.StopIngest()
 
 	// Create and attach new ingest source
-	src := ingest.NewFFmpegURLSource(req.URL, sess.RingBuffer,
-		gw.cfg.MaxIngestDurationSec, gw.logger)
-	sess.SetIngestSource(src)
+	env := ingest.Env{
+		RingBuffer:     sess.RingBuffer,
+		MaxDurationSec: gw.cfg.MaxIngestDurationSec,
+		Logger:         gw.logger,
+		Reconnect:      policy,
+		OnReconnect: func(a ingest.ReconnectAttempt) {
+			metrics.IngestReconnectsTotal.Inc()
+			payload, _ := json.Marshal(datachannel.EventIngestReconnecting{
+				Attempt:     a.Attempt,
+				MaxAttempts: a.MaxAttempts,
+				DelayMs:     a.Delay.Milliseconds(),
+				Error:       a.Err.Error(),
+			})
+			sess.SendDataChannelMessage(datachannel.Envelope{
+				Type:      "ingest.reconnecting",
+				SessionID: sessionID,
+				Timestamp: time.Now().UnixMilli(),
+				Payload:   json.RawMessage(payload),
+			})
+		},
+		OnMetadata: func(m ingest.Metadata) {
+			payload, _ := json.Marshal(datachannel.EventIngestMetadata{
+				Title:       m.Title,
+				Artist:      m.Artist,
+				StreamTitle: m.StreamTitle,
+				OffsetMs:    streamOffsetMs(m.Offset),
+				CapturedAt:  unixMilli(m.At),
+			})
+			sess.SendDataChannelMessage(datachannel.Envelope{
+				Type:      "ingest.metadata",
+				SessionID: sessionID,
+				Timestamp: time.Now().UnixMilli(),
+				Payload:   json.RawMessage(payload),
+			})
+		},
+	}
+	if req.Relay {
+		env.Relay = sess.NewIngestRelay()
+	}
+	source, err := gw.ingestSources.New(src, env)
+	if err != nil {
+		sess.StopIngest() // detaches the relay from the mixer
+		http.Error(w, "create ingest source failed", http.StatusInternalServerError)
+		return
+	}
+	sess.SetIngestSource(source)
 
 	// Send ingest.started event over data channel
-	startedPayload, _ := json.Marshal(datachannel.EventIngestStarted{URL: req.URL})
+	startedPayload, _ := json.Marshal(datachannel.EventIngestStarted{
+		Type: src.Type,
+		URL:  source.Status().SourceURL,
+	})
 	sess.SendDataChannelMessage(datachannel.Envelope{
 		Type:      "ingest.started",
 		SessionID: sessionID,
@@ -234,7 +475,7 @@ func (gw *Gateway) handleIngestStart(w http.ResponseWriter, r *http.Request, ses
 	metrics.ActiveIngests.Inc()
 	go func() {
 		defer metrics.ActiveIngests.Dec()
-		if err := src.Start(context.Background()); err != nil {
+		if err := source.Start(context.Background()); err != nil {
 			gw.logger.Warn("ingest ended with error",
 				zap.String("session", sessionID), zap.Error(err))
 			gw.sendError(sess, sessionID, "", "INGEST_FAILED", err.Error())
@@ -255,6 +496,16 @@ func (gw *Gateway) handleIngestStart(w http.ResponseWriter, r *http.Request, ses
 	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
 }
 
+// reconnectPolicy is the configured default reconnect policy for ingest.
+func (gw *Gateway) reconnectPolicy() ingest.ReconnectPolicy {
+	return ingest.ReconnectPolicy{
+		MaxAttempts:    gw.cfg.IngestReconnectMaxAttempts,
+		InitialBackoff: time.Duration(gw.cfg.IngestReconnectInitialBackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(gw.cfg.IngestReconnectMaxBackoffMs) * time.Millisecond,
+		Jitter:         float64(gw.cfg.IngestReconnectJitterPct) / 100,
+	}
+}
+
 func (gw *Gateway) handleIngestStop(w http.ResponseWriter, r *http.Request, sessionID string) {
 	gw.mu.RLock()
 	sess, ok := gw.sessions[sessionID]
@@ -277,23 +528,82 @@ func (gw *Gateway) handleIngestStatus(w http.ResponseWriter, r *http.Request, se
 		return
 	}
 
-	status := sess.IngestStatus()
+	writeIngestStatus(w, sess.IngestStatus())
+}
+
+func writeIngestStatus(w http.ResponseWriter, status *ingest.Status) {
+	w.Header().Set("Content-Type", "application/json")
 	if status == nil {
-		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(ingestStatusResponse{State: "none"})
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ingestStatusResponse{
+		Type:            status.Type,
 		State:           status.State,
 		SourceURL:       status.SourceURL,
 		SecondsBuffered: status.SecondsBuffered,
 		BytesRead:       status.BytesRead,
 		LastError:       status.LastError,
+		Relaying:        status.Relaying,
+		SegmentsFetched: status.SegmentsFetched,
+		LiveLatencySec:  status.LiveLatencySec,
+		Reconnects:      status.Reconnects,
+		ErrorHistory:    status.ErrorHistory,
+		Metadata:        status.Metadata,
+		Seekable:        status.Seekable,
+		PositionSec:     status.PositionSec,
 	})
 }
 
+// handleIngestSeek moves a file-backed ingest to a position.
+//
+// POST /internal/sessions/{id}/ingest/seek  {"positionSec": 90}
+func (gw *Gateway) handleIngestSeek(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req ingestSeekRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<10)).Decode(&req); err != nil ||
+		req.PositionSec == nil || *req.PositionSec < 0 {
+		http.Error(w, `{"error":"positionSec must be a number >= 0"}`, http.StatusBadRequest)
+		return
+	}
+	pos := time.Duration(*req.PositionSec * float64(time.Second))
+	gw.handleIngestControl(w, sessionID, func(s ingest.Seekable) error { return s.Seek(pos) })
+}
+
+// handleIngestControl applies a pause, resume or seek to the session's ingest
+// and responds with its status. Live streams cannot be controlled (409).
+func (gw *Gateway) handleIngestControl(w http.ResponseWriter, sessionID string, control func(ingest.Seekable) error) {
+	gw.mu.RLock()
+	sess, ok := gw.sessions[sessionID]
+	gw.mu.RUnlock()
+	if !ok {
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+		return
+	}
+
+	src := sess.IngestSource()
+	if src == nil {
+		http.Error(w, `{"error":"no active ingest"}`, http.StatusConflict)
+		return
+	}
+	err := ingest.ErrNotSeekable
+	if s, ok := src.(ingest.Seekable); ok {
+		err = control(s)
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, ingest.ErrNotSeekable) || errors.Is(err, ingest.ErrNotRunning) {
+			code = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	st := src.Status()
+	writeIngestStatus(w, &st)
+}
+
 // handleAudioUpload accepts raw audio bytes (MP3, WAV, etc.), decodes to
 // PCM s16le 16kHz mono via ffmpeg, and writes the result to the ring buffer.
 // This allows mobile clients to upload local audio for server-side enunciation.
@@ -415,3 +725,113 @@ func (gw *Gateway) handleAudioUpload(w http.ResponseWriter, r *http.Request, ses
 		"audioSeconds":    pcmSeconds,
 	})
 }
+
+// maxSnapshotExportSec caps the length of an exported audio snapshot.
+const maxSnapshotExportSec = 600
+
+// handleAudioSnapshot exports buffered session audio for offline inspection,
+// e.g. to reproduce a bad transcription.
+//
+// GET /internal/sessions/{id}/audio/snapshot?fromMs=12300&toMs=17800&format=wav
+//
+// Query params (optional):
+//   - fromMs, toMs: range on the stream timeline (ms since the first buffered audio),
+//     the same timeline as command.enunciate fromMs/toMs. Default: everything buffered,
+//     from at most maxSnapshotExportSec before toMs.
+//   - format: "wav" (PCM s16le 16kHz mono, default) or "ogg" (Ogg/Opus)
+//
+// Responds 410 if the range has been overwritten, 400 if it is not buffered yet.
+func (gw *Gateway) handleAudioSnapshot(w http.ResponseWriter, r *http.Request, sessionID string) {
+	gw.mu.RLock()
+	sess, ok := gw.sessions[sessionID]
+	gw.mu.RUnlock()
+	if !ok {
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "wav"
+	}
+	if format != "wav" && format != "ogg" {
+		http.Error(w, `{"error":"format must be wav or ogg"}`, http.StatusBadRequest)
+		return
+	}
+
+	start, end := sess.RingBuffer.Oldest(), sess.RingBuffer.Written()
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"fromMs", &start}, {"toMs", &end}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid %s"}`, p.name), http.StatusBadRequest)
+			return
+		}
+		*p.dst = ringbuffer.OffsetForMs(ms)
+	}
+	if q.Get("fromMs") == "" {
+		// A DVR buffer can hold more than can be exported at once: default to
+		// the most recent part of it.
+		start = max(start, end-maxSnapshotExportSec*ringbuffer.BytesPerSecond)
+	}
+	if end <= start {
+		http.Error(w, `{"error":"empty range: toMs must be greater than fromMs"}`, http.StatusBadRequest)
+		return
+	}
+	if end-start > maxSnapshotExportSec*ringbuffer.BytesPerSecond {
+		http.Error(w, fmt.Sprintf(`{"error":"range exceeds %ds"}`, maxSnapshotExportSec), http.StatusBadRequest)
+		return
+	}
+
+	pcm, err := sess.RingBuffer.SnapshotRange(start, end, make([]byte, end-start))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ringbuffer.ErrRangeOverwritten):
+			status = http.StatusGone
+		case errors.Is(err, ringbuffer.ErrRangeNotWritten), errors.Is(err, ringbuffer.ErrInvalidRange):
+			status = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%.0f-%.0f.%s", sessionID, streamOffsetMs(start), streamOffsetMs(end), format)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if ts := unixMilli(sess.RingBuffer.TimeAt(start)); ts != 0 {
+		w.Header().Set("X-Capture-Start", strconv.FormatInt(ts, 10))
+	}
+
+	gw.logger.Info("exporting audio snapshot",
+		zap.String("session", sessionID),
+		zap.String("format", format),
+		zap.Int("bytes", len(pcm)),
+	)
+
+	if format == "ogg" {
+		// Encode before writing so a failure can still be reported as an error.
+		var buf bytes.Buffer
+		if err := audio.WriteOggOpus(&buf, pcm); err != nil {
+			gw.logger.Error("ogg export failed", zap.String("session", sessionID), zap.Error(err))
+			http.Error(w, `{"error":"opus encode failed"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		buf.WriteTo(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("Content-Length", strconv.Itoa(44+len(pcm)))
+	audio.WriteWAV(w, pcm, 16000)
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/metrics"
+)
+
+// ttsSession is the part of a session that TTS playback uses.
+type ttsSession interface {
+	BeginSynthesis(actionID string, opts datachannel.TTSOptions) datachannel.TTSOptions
+	PlayAudioStream(ctx context.Context, chunks <-chan audio.Chunk) error
+	ScheduleMarks(actionID string, marks datachannel.EventTtsMarks) datachannel.EventTtsMarks
+	SendDataChannelMessage(msg interface{}) error
+}
+
+// speak synthesizes text and plays it on the session's outbound track, emitting
+// tts.marks / tts.started / tts.done along the way.
+//
+// In buffered mode (default) every chunk is collected first so that tts.marks can
+// be sent before playback. In streaming mode playback starts on the first chunk
+// and the marks follow as tts.marks.update once synthesis has finished.
+//
+// Returns the time to first TTS chunk and false if the action ended early
+// (the outcome has already been logged and counted).
+func (gw *Gateway) speak(ctx context.Context, sess ttsSession, sessionID, actionID,
+	text, language string, opts datachannel.TTSOptions, logger *zap.Logger) (float64, bool) {
+
+	ttsStart := time.Now()
+	// Pick up any command.update received while ASR/translation was running.
+	opts = sess.BeginSynthesis(actionID, opts)
+	voice := opts.Voice
+	if voice == "" {
+		voice = "default"
+	}
+	speed := float32(opts.Speed)
+	if speed <= 0 {
+		speed = 1.0
+	}
+
+	rawChunks, errs := gw.inferenceClient.SynthesizeStream(ctx, text,
+		sessionID, actionID, voice, language, speed)
+
+	var ttsFirstChunkMs float64
+	var playErr error
+	if opts.IsStreaming() {
+		ttsFirstChunkMs, playErr = gw.playStreaming(ctx, sess, sessionID, actionID, text, rawChunks, ttsStart, logger)
+	} else {
+		ttsFirstChunkMs, playErr = gw.playBuffered(ctx, sess, sessionID, actionID, text, rawChunks, ttsStart, logger)
+	}
+
+	if playErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			logger.Warn("enunciate timed out during TTS playback")
+			metrics.InferenceTimeoutsTotal.Inc()
+			metrics.ActionsTotal.WithLabelValues("timeout").Inc()
+			return ttsFirstChunkMs, false
+		}
+		if ctx.Err() != nil {
+			logger.Info("enunciate cancelled during TTS playback")
+			metrics.ActionsTotal.WithLabelValues(cancelledOutcome(ctx)).Inc()
+			return ttsFirstChunkMs, false
+		}
+		logger.Warn("TTS playback error", zap.Error(playErr))
+		metrics.ActionsTotal.WithLabelValues("tts_error").Inc()
+		return ttsFirstChunkMs, false
+	}
+
+	// Check for gRPC errors
+	select {
+	case err := <-errs:
+		if err != nil {
+			logger.Warn("TTS stream error", zap.Error(err))
+		}
+	default:
+	}
+
+	// Send tts.done event
+	ttsDuration := time.Since(ttsStart)
+	ttsDonePayload, _ := json.Marshal(datachannel.EventTtsDone{
+		DurationMs: int(ttsDuration.Milliseconds()),
+	})
+	sess.SendDataChannelMessage(datachannel.Envelope{
+		Type:      "tts.done",
+		SessionID: sessionID,
+		ActionID:  actionID,
+		Timestamp: time.Now().UnixMilli(),
+		Payload:   json.RawMessage(ttsDonePayload),
+	})
+
+	return ttsFirstChunkMs, true
+}
+
+// playBuffered drains every TTS chunk, sends tts.marks and tts.started, then plays.
+func (gw *Gateway) playBuffered(ctx context.Context, sess ttsSession, sessionID, actionID,
+	text string, rawChunks <-chan audio.Chunk, ttsStart time.Time, logger *zap.Logger) (float64, error) {
+
+	// Buffer all TTS chunks so we can calculate word marks
+	// before starting playback.
+	var allChunks []audio.Chunk
+	var totalDuration time.Duration
+	var ttsFirstChunkMs float64
+	first := true
+	for chunk := range rawChunks {
+		if first {
+			ttsFirstChunkMs = float64(time.Since(ttsStart).Microseconds()) / 1000.0
+			first = false
+		}
+		allChunks = append(allChunks, chunk)
+		totalDuration += chunk.Format.Duration(chunk.Data)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// Cancelled while synthesizing: nothing is played.
+	if err := ctx.Err(); err != nil {
+		return ttsFirstChunkMs, err
+	}
+
+	// Calculate word marks and send tts.marks event before playback.
+	if totalDuration > 0 {
+		gw.sendWordMarks(sess, sessionID, actionID, "tts.marks", text, totalDuration)
+	}
+
+	// Send tts.started AFTER marks so the client has word data ready
+	// when it begins scheduling highlight timers.
+	gw.sendTtsStarted(sess, sessionID, actionID)
+
+	// Feed buffered chunks to PlayAudioStream.
+	bufferedCh := make(chan audio.Chunk, len(allChunks))
+	for _, chunk := range allChunks {
+		bufferedCh <- chunk
+	}
+	close(bufferedCh)
+
+	logger.Info("starting TTS playback",
+		zap.Int("chunks", len(allChunks)),
+		zap.Duration("audioDuration", totalDuration),
+	)
+
+	return ttsFirstChunkMs, sess.PlayAudioStream(ctx, bufferedCh)
+}
+
+// playStreaming starts playback as soon as the first TTS chunk arrives and forwards
+// the rest as they are synthesized. Word marks are sent as tts.marks.update once
+// the synthesis stream ends, while playback is typically still in progress.
+func (gw *Gateway) playStreaming(ctx context.Context, sess ttsSession, sessionID, actionID,
+	text string, rawChunks <-chan audio.Chunk, ttsStart time.Time, logger *zap.Logger) (float64, error) {
+
+	var firstChunk audio.Chunk
+	select {
+	case chunk, ok := <-rawChunks:
+		if !ok {
+			// Nothing synthesized — mirror buffered mode and play an empty stream.
+			gw.sendTtsStarted(sess, sessionID, actionID)
+			empty := make(chan audio.Chunk)
+			close(empty)
+			return 0, sess.PlayAudioStream(ctx, empty)
+		}
+		firstChunk = chunk
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	ttsFirstChunkMs := float64(time.Since(ttsStart).Microseconds()) / 1000.0
+
+	gw.sendTtsStarted(sess, sessionID, actionID)
+	logger.Info("starting streaming TTS playback", zap.Float64("ttsFirstChunkMs", ttsFirstChunkMs))
+
+	playCh := make(chan audio.Chunk, 16)
+	playDone := make(chan struct{})
+
+	// The forwarder closes playCh after the last chunk (and marks), which is what
+	// lets PlayAudioStream return nil. On early exit it is unblocked by playDone or
+	// by ctx, which FinishAction always cancels.
+	go func() {
+		defer close(playCh)
+
+		totalDuration := firstChunk.Format.Duration(firstChunk.Data)
+		playCh <- firstChunk
+		for chunk := range rawChunks {
+			totalDuration += chunk.Format.Duration(chunk.Data)
+			select {
+			case playCh <- chunk:
+			case <-playDone:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+		if totalDuration > 0 && ctx.Err() == nil {
+			gw.sendWordMarks(sess, sessionID, actionID, "tts.marks.update", text, totalDuration)
+		}
+	}()
+
+	err := sess.PlayAudioStream(ctx, playCh)
+	close(playDone)
+	return ttsFirstChunkMs, err
+}
+
+// sendWordMarks estimates per-word timing from the total audio duration and sends
+// it as the given event type (tts.marks or tts.marks.update), adjusted for any
+// playback rate change.
+func (gw *Gateway) sendWordMarks(sess ttsSession, sessionID, actionID, eventType,
+	text string, totalDuration time.Duration) {
+
+	totalDurationMs := float64(totalDuration.Microseconds()) / 1000.0
+	sendMarks(sess, sessionID, actionID, eventType, sess.ScheduleMarks(actionID, datachannel.EventTtsMarks{
+		Text:       text,
+		Words:      calculateWordMarks(text, totalDurationMs),
+		DurationMs: totalDurationMs,
+	}))
+}
+
+func sendMarks(sess ttsSession, sessionID, actionID, eventType string, marks datachannel.EventTtsMarks) {
+	marksPayload, _ := json.Marshal(marks)
+	sess.SendDataChannelMessage(datachannel.Envelope{
+		Type:      eventType,
+		SessionID: sessionID,
+		ActionID:  actionID,
+		Timestamp: time.Now().UnixMilli(),
+		Payload:   json.RawMessage(marksPayload),
+	})
+}
+
+func (gw *Gateway) sendTtsStarted(sess ttsSession, sessionID, actionID string) {
+	sess.SendDataChannelMessage(datachannel.Envelope{
+		Type:      "tts.started",
+		SessionID: sessionID,
+		ActionID:  actionID,
+		Timestamp: time.Now().UnixMilli(),
+		Payload:   json.RawMessage(`{"voice":"default"}`),
+	})
+}
@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/datachannel"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/inference"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/session"
+)
+
+// recorder records the events a session sends, in order, together with a
+// "play" entry for each chunk of audio played.
+type recorder struct {
+	mu     sync.Mutex
+	events []datachannel.Envelope
+}
+
+func (r *recorder) SendText(text string) error {
+	var env datachannel.Envelope
+	if err := json.Unmarshal([]byte(text), &env); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.events = append(r.events, env)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recorder) played() {
+	r.mu.Lock()
+	r.events = append(r.events, datachannel.Envelope{Type: "play"})
+	r.mu.Unlock()
+}
+
+// types returns the recorded event types.
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// event returns the first recorded event of the given type.
+func (r *recorder) event(typ string) (datachannel.Envelope, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.events {
+		if e.Type == typ {
+			return e, true
+		}
+	}
+	return datachannel.Envelope{}, false
+}
+
+// testSession is a session whose outbound audio is recorded instead of encoded.
+// playing, when set, is closed as the first chunk is played, and hold makes
+// playback wait for ctx after it.
+type testSession struct {
+	*session.Session
+	rec     *recorder
+	playing chan struct{}
+	hold    bool
+}
+
+func newTestSession(t *testing.T) *testSession {
+	t.Helper()
+	sess := session.New("sess-1", 5, zap.NewNop())
+	rec := &recorder{}
+	sess.SetMessageSender(rec)
+	t.Cleanup(sess.Stop)
+	return &testSession{Session: sess, rec: rec}
+}
+
+func (s *testSession) PlayAudioStream(ctx context.Context, chunks <-chan audio.Chunk) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-chunks:
+			if !ok {
+				return nil
+			}
+			s.rec.played()
+			if s.playing != nil {
+				close(s.playing)
+				s.playing = nil
+				if s.hold {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+func newTestGateway(client inference.InferenceClient) *Gateway {
+	return &Gateway{logger: zap.NewNop(), inferenceClient: client, sessions: make(map[string]*session.Session)}
+}
+
+func TestSpeakBuffered(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{TTSChunkCount: 4, TTSChunkDelay: time.Millisecond})
+	sess := newTestSession(t)
+	ctx := sess.TryStartAction("a1", time.Minute)
+
+	if _, ok := gw.speak(ctx, sess, "sess-1", "a1", "hello there world", "en",
+		datachannel.TTSOptions{}, zap.NewNop()); !ok {
+		t.Fatal("speak failed")
+	}
+
+	want := []string{"tts.marks", "tts.started", "play", "play", "play", "play", "tts.done"}
+	if got := sess.rec.types(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	checkMarks(t, sess.rec, "tts.marks", 3, 400) // 4 chunks of 100ms
+}
+
+func TestSpeakStreaming(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{TTSChunkCount: 4, TTSChunkDelay: time.Millisecond})
+	sess := newTestSession(t)
+	ctx := sess.TryStartAction("a1", time.Minute)
+
+	streaming := true
+	if _, ok := gw.speak(ctx, sess, "sess-1", "a1", "hello there world", "en",
+		datachannel.TTSOptions{Streaming: &streaming}, zap.NewNop()); !ok {
+		t.Fatal("speak failed")
+	}
+
+	// Playback starts before synthesis ends; the marks follow as an update.
+	got := sess.rec.types()
+	if len(got) != 7 || got[0] != "tts.started" || got[1] != "play" || got[6] != "tts.done" {
+		t.Fatalf("events = %v", got)
+	}
+	if slices.Contains(got, "tts.marks") || slices.Index(got, "tts.marks.update") < 2 {
+		t.Errorf("events = %v, want tts.marks.update after playback started", got)
+	}
+	checkMarks(t, sess.rec, "tts.marks.update", 3, 400)
+}
+
+func TestSpeakModeFromSessionDefault(t *testing.T) {
+	gw := newTestGateway(&inference.MockClient{TTSChunkCount: 2, TTSChunkDelay: time.Millisecond})
+	sess := newTestSession(t)
+	streaming := true
+	sess.UpdateDefaults(nil, datachannel.TTSOptions{Streaming: &streaming})
+
+	opts := withTTSDefaults(datachannel.TTSOptions{}, sess.Defaults().TTSOptions)
+	ctx := sess.TryStartAction("a1", time.Minute)
+	gw.speak(ctx, sess, "sess-1", "a1", "hi", "en", opts, zap.NewNop())
+	if got := sess.rec.types(); got[0] != "tts.started" {
+		t.Errorf("events = %v, want streaming playback from the session default", got)
+	}
+
+	// An enunciate can still ask for buffered playback.
+	buffered := false
+	if opts := withTTSDefaults(datachannel.TTSOptions{Streaming: &buffered}, sess.Defaults().TTSOptions); opts.IsStreaming() {
+		t.Error("explicit streaming:false overridden by the session default")
+	}
+}
+
+func checkMarks(t *testing.T, rec *recorder, typ string, words int, durationMs float64) {
+	t.Helper()
+	e, ok := rec.event(typ)
+	if !ok {
+		t.Fatalf("no %s event", typ)
+	}
+	var marks datachannel.EventTtsMarks
+	if err := json.Unmarshal(e.Payload, &marks); err != nil {
+		t.Fatal(err)
+	}
+	if len(marks.Words) != words || marks.DurationMs != durationMs ||
+		marks.Words[words-1].EndMs > durationMs {
+		t.Errorf("%s = %+v", typ, marks)
+	}
+}
@@ -2,22 +2,31 @@ package inference
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	whatsv1 "github.com/RenatoCabral2022/WhatsWebService/gen/go/whats/v1"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
 )
 
 // InferenceClient defines the interface for inference service calls.
 // Extracted for testability (mock injection in soak tests).
 type InferenceClient interface {
 	Transcribe(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string) (*whatsv1.TranscribeResponse, error)
-	SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan []byte, <-chan error)
+	TranscribeStream(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string, onPartial PartialFunc) (*whatsv1.TranscribeResponse, error)
+	SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan audio.Chunk, <-chan error)
 	Close()
 }
 
+// PartialFunc receives intermediate ASR hypotheses from TranscribeStream.
+// It is called synchronously on the receiving goroutine and must not block.
+type PartialFunc func(partial *whatsv1.TranscribePartial)
+
 // Verify Client implements InferenceClient at compile time.
 var _ InferenceClient = (*Client)(nil)
 
@@ -72,11 +81,55 @@ func (c *Client) Transcribe(ctx context.Context, audio []byte, sessionID, action
 	})
 }
 
+// TranscribeStream sends audio to the ASR service and calls onPartial for each
+// partial hypothesis as it arrives, returning the final transcription.
+// Falls back to unary Transcribe when the ASR service does not implement streaming.
+func (c *Client) TranscribeStream(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string, onPartial PartialFunc) (*whatsv1.TranscribeResponse, error) {
+	stream, err := c.asrClient.TranscribeStream(ctx, &whatsv1.TranscribeRequest{
+		Audio: audio,
+		Format: &whatsv1.AudioFormat{
+			SampleRate: 16000,
+			Channels:   1,
+			Encoding:   whatsv1.AudioEncoding_AUDIO_ENCODING_PCM_S16LE,
+		},
+		SessionId:      sessionID,
+		ActionId:       actionID,
+		LanguageHint:   languageHint,
+		Task:           task,
+		TargetLanguage: targetLanguage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	received := false
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("asr stream ended without a final result")
+		}
+		if err != nil {
+			if !received && status.Code(err) == codes.Unimplemented {
+				return c.Transcribe(ctx, audio, sessionID, actionID, languageHint, task, targetLanguage)
+			}
+			return nil, err
+		}
+		received = true
+
+		if final := resp.GetFinal(); final != nil {
+			return final, nil
+		}
+		if partial := resp.GetPartial(); partial != nil && onPartial != nil {
+			onPartial(partial)
+		}
+	}
+}
+
 // SynthesizeStream calls TTS and returns channels for audio chunks and errors.
-// The chunks channel receives PCM s16le 16kHz mono byte slices.
-// Both channels are closed when the stream ends.
-func (c *Client) SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan []byte, <-chan error) {
-	chunks := make(chan []byte, 16)
+// Each chunk carries the format the engine declared for it (PCM s16le 16kHz mono
+// if it never declared one). Both channels are closed when the stream ends.
+func (c *Client) SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan audio.Chunk, <-chan error) {
+	chunks := make(chan audio.Chunk, 16)
 	errs := make(chan error, 1)
 
 	go func() {
@@ -101,6 +154,7 @@ func (c *Client) SynthesizeStream(ctx context.Context, text, sessionID, actionID
 			return
 		}
 
+		format := audio.PCM16kMono
 		for {
 			resp, err := stream.Recv()
 			if err == io.EOF {
@@ -110,9 +164,12 @@ func (c *Client) SynthesizeStream(ctx context.Context, text, sessionID, actionID
 				errs <- err
 				return
 			}
+			if resp.Format != nil {
+				format = formatFromProto(resp.Format)
+			}
 			if resp.Chunk != nil && len(resp.Chunk.Data) > 0 {
 				select {
-				case chunks <- resp.Chunk.Data:
+				case chunks <- audio.Chunk{Data: resp.Chunk.Data, Format: format}:
 				case <-ctx.Done():
 					return
 				}
@@ -126,6 +183,25 @@ func (c *Client) SynthesizeStream(ctx context.Context, text, sessionID, actionID
 	return chunks, errs
 }
 
+// formatFromProto maps a declared AudioFormat onto audio.Format, filling unset
+// fields from the canonical 16kHz mono s16le format.
+func formatFromProto(f *whatsv1.AudioFormat) audio.Format {
+	format := audio.PCM16kMono
+	if f.SampleRate > 0 {
+		format.SampleRate = int(f.SampleRate)
+	}
+	if f.Channels > 0 {
+		format.Channels = int(f.Channels)
+	}
+	switch f.Encoding {
+	case whatsv1.AudioEncoding_AUDIO_ENCODING_PCM_F32LE:
+		format.Encoding = audio.EncodingPCMF32LE
+	case whatsv1.AudioEncoding_AUDIO_ENCODING_OPUS:
+		format.Encoding = audio.EncodingOpus
+	}
+	return format
+}
+
 // Close shuts down all gRPC connections.
 func (c *Client) Close() {
 	if c.asrConn != nil {
@@ -0,0 +1,117 @@
+package inference
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	whatsv1 "github.com/RenatoCabral2022/WhatsWebService/gen/go/whats/v1"
+)
+
+// fakeASR is an AsrServiceClient whose stream replays canned responses and
+// then err (io.EOF if nil).
+type fakeASR struct {
+	whatsv1.AsrServiceClient
+	responses []*whatsv1.TranscribeStreamResponse
+	err       error
+	unary     int
+}
+
+func (f *fakeASR) Transcribe(ctx context.Context, in *whatsv1.TranscribeRequest, opts ...grpc.CallOption) (*whatsv1.TranscribeResponse, error) {
+	f.unary++
+	return &whatsv1.TranscribeResponse{Text: "unary", Language: "en"}, nil
+}
+
+func (f *fakeASR) TranscribeStream(ctx context.Context, in *whatsv1.TranscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[whatsv1.TranscribeStreamResponse], error) {
+	return &fakeStream{asr: f}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	asr *fakeASR
+	n   int
+}
+
+func (s *fakeStream) Recv() (*whatsv1.TranscribeStreamResponse, error) {
+	if s.n < len(s.asr.responses) {
+		s.n++
+		return s.asr.responses[s.n-1], nil
+	}
+	if s.asr.err != nil {
+		return nil, s.asr.err
+	}
+	return nil, io.EOF
+}
+
+func partialResponse(text string) *whatsv1.TranscribeStreamResponse {
+	return &whatsv1.TranscribeStreamResponse{Result: &whatsv1.TranscribeStreamResponse_Partial{
+		Partial: &whatsv1.TranscribePartial{Text: text, Language: "en"},
+	}}
+}
+
+func finalResponse(text string) *whatsv1.TranscribeStreamResponse {
+	return &whatsv1.TranscribeStreamResponse{Result: &whatsv1.TranscribeStreamResponse_Final{
+		Final: &whatsv1.TranscribeResponse{Text: text, Language: "en"},
+	}}
+}
+
+func transcribe(asr *fakeASR) (*whatsv1.TranscribeResponse, []string, error) {
+	c := &Client{asrClient: asr}
+	var partials []string
+	resp, err := c.TranscribeStream(context.Background(), nil, "s", "a", "", "transcribe", "",
+		func(p *whatsv1.TranscribePartial) { partials = append(partials, p.Text) })
+	return resp, partials, err
+}
+
+func TestTranscribeStreamPartials(t *testing.T) {
+	asr := &fakeASR{responses: []*whatsv1.TranscribeStreamResponse{
+		partialResponse("hello"), partialResponse("hello there"), finalResponse("hello there world"),
+	}}
+	resp, partials, err := transcribe(asr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "hello there world" || asr.unary != 0 {
+		t.Errorf("resp = %q, unary calls = %d", resp.Text, asr.unary)
+	}
+	if want := []string{"hello", "hello there"}; !slices.Equal(partials, want) {
+		t.Errorf("partials = %v, want %v", partials, want)
+	}
+}
+
+func TestTranscribeStreamUnimplementedFallback(t *testing.T) {
+	asr := &fakeASR{err: status.Error(codes.Unimplemented, "unknown method TranscribeStream")}
+	resp, partials, err := transcribe(asr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "unary" || asr.unary != 1 || partials != nil {
+		t.Errorf("resp = %q, unary calls = %d, partials = %v", resp.Text, asr.unary, partials)
+	}
+}
+
+func TestTranscribeStreamErrors(t *testing.T) {
+	// Unimplemented after a partial is a real failure, not an old server.
+	asr := &fakeASR{
+		responses: []*whatsv1.TranscribeStreamResponse{partialResponse("hello")},
+		err:       status.Error(codes.Unimplemented, "gone"),
+	}
+	if _, _, err := transcribe(asr); status.Code(err) != codes.Unimplemented || asr.unary != 0 {
+		t.Errorf("err = %v, unary calls = %d", err, asr.unary)
+	}
+
+	asr = &fakeASR{err: status.Error(codes.Unavailable, "down")}
+	if _, _, err := transcribe(asr); status.Code(err) != codes.Unavailable || asr.unary != 0 {
+		t.Errorf("err = %v, unary calls = %d", err, asr.unary)
+	}
+
+	asr = &fakeASR{responses: []*whatsv1.TranscribeStreamResponse{partialResponse("hello")}}
+	if _, _, err := transcribe(asr); err == nil {
+		t.Error("stream ended without a final: no error")
+	}
+}
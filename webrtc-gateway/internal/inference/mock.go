@@ -2,9 +2,11 @@ package inference
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	whatsv1 "github.com/RenatoCabral2022/WhatsWebService/gen/go/whats/v1"
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/audio"
 )
 
 // MockClient returns canned responses for testing.
@@ -13,7 +15,8 @@ type MockClient struct {
 	TranscribeText  string
 	TTSChunkDelay   time.Duration
 	TTSChunkCount   int
-	TTSChunkSize    int // bytes per chunk (default 3200 = 100ms at 16kHz)
+	TTSChunkSize    int          // bytes per chunk (default 3200 = 100ms at 16kHz)
+	TTSFormat       audio.Format // declared chunk format (default PCM s16le 16kHz mono)
 }
 
 func (m *MockClient) Transcribe(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string) (*whatsv1.TranscribeResponse, error) {
@@ -22,6 +25,40 @@ func (m *MockClient) Transcribe(ctx context.Context, audio []byte, sessionID, ac
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
+	return m.response(targetLanguage), nil
+}
+
+// TranscribeStream emits one cumulative partial per word, spread evenly across
+// TranscribeDelay, then returns the same result as Transcribe.
+func (m *MockClient) TranscribeStream(ctx context.Context, audio []byte, sessionID, actionID, languageHint, task, targetLanguage string, onPartial PartialFunc) (*whatsv1.TranscribeResponse, error) {
+	text := m.TranscribeText
+	if text == "" {
+		text = "hello world"
+	}
+	words := strings.Fields(text)
+	step := m.TranscribeDelay / time.Duration(len(words)+1)
+	for i := range words {
+		select {
+		case <-time.After(step):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if onPartial != nil {
+			onPartial(&whatsv1.TranscribePartial{
+				Text:     strings.Join(words[:i+1], " "),
+				Language: "en",
+			})
+		}
+	}
+	select {
+	case <-time.After(step):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return m.response(targetLanguage), nil
+}
+
+func (m *MockClient) response(targetLanguage string) *whatsv1.TranscribeResponse {
 	text := m.TranscribeText
 	if text == "" {
 		text = "hello world"
@@ -35,10 +72,10 @@ func (m *MockClient) Transcribe(ctx context.Context, audio []byte, sessionID, ac
 		resp.TargetLanguage = targetLanguage
 		resp.TranslateDurationMs = 50
 	}
-	return resp, nil
+	return resp
 }
 
-func (m *MockClient) SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan []byte, <-chan error) {
+func (m *MockClient) SynthesizeStream(ctx context.Context, text, sessionID, actionID, voice, language string, speed float32) (<-chan audio.Chunk, <-chan error) {
 	count := m.TTSChunkCount
 	if count == 0 {
 		count = 10
@@ -48,7 +85,12 @@ func (m *MockClient) SynthesizeStream(ctx context.Context, text, sessionID, acti
 		chunkSize = 3200
 	}
 
-	chunks := make(chan []byte, count)
+	format := m.TTSFormat
+	if format.SampleRate == 0 {
+		format = audio.PCM16kMono
+	}
+
+	chunks := make(chan audio.Chunk, count)
 	errs := make(chan error, 1)
 
 	go func() {
@@ -61,7 +103,7 @@ func (m *MockClient) SynthesizeStream(ctx context.Context, text, sessionID, acti
 				return
 			}
 			select {
-			case chunks <- make([]byte, chunkSize):
+			case chunks <- audio.Chunk{Data: make([]byte, chunkSize), Format: format}:
 			case <-ctx.Done():
 				return
 			}
@@ -0,0 +1,160 @@
+package ingest
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// sniffBytes is how much of the audio data (past any ID3 tag) is scanned
+	// for frame headers to estimate the byte rate.
+	sniffBytes = 32 << 10
+	// maxSniffBytes bounds the head kept for sniffing, ID3 tag (cover art) included.
+	maxSniffBytes = 2 << 20
+	// minSniffFrames is how many consecutive frames must parse for an estimate.
+	minSniffFrames = 4
+)
+
+// byteRate maps media positions to byte offsets in a compressed source, so it
+// can be opened near a position instead of decoded from the start.
+type byteRate struct {
+	dataStart int64   // offset of the first audio frame
+	perSecond float64 // average bytes per second of audio
+}
+
+// offset returns the estimated byte offset of media position pos.
+func (r byteRate) offset(pos time.Duration) int64 {
+	return r.dataStart + int64(pos.Seconds()*r.perSecond)
+}
+
+// sniffByteRate estimates the byte rate of MPEG audio (MP3, MP2) or ADTS AAC
+// from the frames at the start of head. Both resynchronize on the next frame
+// header, so decoding can start at any byte offset. The estimate is exact for
+// constant bitrate; for variable bitrate it is the average of the frames seen.
+// ok is false for other formats, or if head is too short to tell.
+func sniffByteRate(head []byte) (r byteRate, ok bool) {
+	start, complete := id3v2Size(head)
+	if !complete {
+		return r, false
+	}
+	var bytes, seconds float64
+	frames := 0
+	for pos := start; pos+7 <= len(head); {
+		n, sec := frameAt(head[pos:])
+		if n == 0 {
+			break
+		}
+		bytes += float64(n)
+		seconds += sec
+		frames++
+		pos += n
+	}
+	if frames < minSniffFrames {
+		return r, false
+	}
+	return byteRate{dataStart: int64(start), perSecond: bytes / seconds}, true
+}
+
+// id3v2Size returns the length of the ID3v2 tag at the start of head (0 if
+// none), and whether head extends past it.
+func id3v2Size(head []byte) (int, bool) {
+	if len(head) < 10 || string(head[:3]) != "ID3" {
+		return 0, len(head) >= 10
+	}
+	size := 10 + (int(head[6]&0x7f)<<21 | int(head[7]&0x7f)<<14 | int(head[8]&0x7f)<<7 | int(head[9]&0x7f))
+	if head[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	return size, len(head) > size
+}
+
+// MPEG audio bitrates in kbps by [version 1 or 2][layer 1-3][index].
+var mpegBitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpegSampleRates by version bits (0 = MPEG 2.5, 2 = MPEG 2, 3 = MPEG 1) and index.
+var mpegSampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+var adtsSampleRates = [16]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// frameAt parses the MPEG audio or ADTS frame header at the start of b and
+// returns the frame's length in bytes and duration in seconds, or 0 if b does
+// not start with a valid header.
+func frameAt(b []byte) (int, float64) {
+	if len(b) < 7 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return 0, 0
+	}
+	if b[1]&0xf6 == 0xf0 { // ADTS: 12-bit sync, layer 0
+		rate := adtsSampleRates[b[2]>>2&0x0f]
+		n := int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5])>>5
+		if rate == 0 || n < 7 {
+			return 0, 0
+		}
+		blocks := int(b[6]&0x03) + 1
+		return n, float64(blocks*1024) / float64(rate)
+	}
+
+	version, layer := b[1]>>3&0x03, 4-int(b[1]>>1&0x03)
+	brIdx, srIdx := b[2]>>4, b[2]>>2&0x03
+	if version == 1 || layer == 4 || brIdx == 0 || brIdx == 15 || srIdx == 3 {
+		return 0, 0
+	}
+	v := 1
+	if version == 3 {
+		v = 0
+	}
+	bitrate := mpegBitrates[v][layer-1][brIdx] * 1000
+	rate := mpegSampleRates[version][srIdx]
+	pad := int(b[2] >> 1 & 0x01)
+
+	var n, samples int
+	switch {
+	case layer == 1:
+		n, samples = (12*bitrate/rate+pad)*4, 384
+	case layer == 3 && v == 1:
+		n, samples = 72*bitrate/rate+pad, 576
+	default:
+		n, samples = 144*bitrate/rate+pad, 1152
+	}
+	return n, float64(samples) / float64(rate)
+}
+
+// sniffReader passes a source through while keeping its head, and reports the
+// byte rate once enough of it has been read to tell.
+type sniffReader struct {
+	io.ReadCloser
+	head   []byte
+	report func(byteRate)
+	done   bool
+}
+
+func (s *sniffReader) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if !s.done && (n > 0 || err != nil) {
+		s.head = append(s.head, p[:n]...)
+		start, complete := id3v2Size(s.head)
+		if complete && len(s.head) >= start+sniffBytes || len(s.head) >= maxSniffBytes || err != nil {
+			s.done = true
+			if r, ok := sniffByteRate(s.head); ok {
+				s.report(r)
+			}
+			s.head = nil
+		}
+	}
+	return n, err
+}
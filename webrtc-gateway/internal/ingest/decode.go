@@ -0,0 +1,189 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// ffmpegPath is the decoder binary run by decode.
+var ffmpegPath = "ffmpeg"
+
+// decodeArgs make ffmpeg decode whatever arrives on stdin to PCM s16le 16kHz
+// mono on stdout. ffmpeg never fetches anything itself: network input is read
+// by a Go client (NewHTTPClient) and piped in.
+var decodeArgs = []string{
+	"-hide_banner", "-loglevel", "error",
+	"-i", "pipe:0",
+	"-vn",
+	"-ac", "1",
+	"-ar", "16000",
+	"-f", "s16le",
+	"pipe:1",
+}
+
+// pcmSink is where decoded audio goes.
+type pcmSink struct {
+	rb        *ringbuffer.RingBuffer
+	relay     Relay
+	bytesRead *atomic.Int64
+	meta      *metadataQueue // nil when metadata is not reported
+	logger    *zap.Logger
+	// start is the media position the audio begins at. origin, when shared
+	// across decodes of one source, keeps the timestamps of audio after a
+	// pause or seek on the same timeline: wall time at media position zero.
+	start  time.Duration
+	origin *time.Time
+	// skip is how much of the input ffmpeg decodes and discards before
+	// output starts, when it could not be opened at start.
+	skip time.Duration
+}
+
+// decodeArgsFrom returns decodeArgs with the output starting start into the
+// input. The input is a pipe, so ffmpeg decodes and discards up to there: the
+// cost grows with start, which is why sources are opened near the position
+// they resume at where they can be (see FFmpegURLSource.openAt).
+func decodeArgsFrom(start time.Duration) []string {
+	if start <= 0 {
+		return decodeArgs
+	}
+	args := make([]string, 0, len(decodeArgs)+2)
+	for _, a := range decodeArgs {
+		args = append(args, a)
+		if a == "pipe:0" {
+			args = append(args, "-ss", strconv.FormatFloat(start.Seconds(), 'f', 3, 64))
+		}
+	}
+	return args
+}
+
+// decode runs ffmpeg on what feed writes to its stdin and copies the PCM it
+// produces to sink until the input ends or ctx is cancelled. started is called
+// once ffmpeg is running. The feed's own error (a failed fetch) is preferred
+// over ffmpeg's, which would only report that its input ran dry.
+func decode(ctx context.Context, feed func(context.Context, io.Writer) error,
+	started func(), sink pcmSink) error {
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, decodeArgsFrom(sink.skip)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg start: %w", err)
+	}
+	started()
+
+	// Closing stdin after the input ends lets ffmpeg flush and exit.
+	feedCtx, stopFeed := context.WithCancel(ctx)
+	defer stopFeed()
+	feedDone := make(chan error, 1)
+	go func() {
+		err := feed(feedCtx, stdin)
+		stdin.Close()
+		feedDone <- err
+	}()
+
+	readErr := readPCM(ctx, stdout, sink)
+	waitErr := cmd.Wait()
+	stopFeed()
+	feedErr := <-feedDone
+
+	switch {
+	case feedErr != nil && !errors.Is(feedErr, context.Canceled) && !errors.Is(feedErr, syscall.EPIPE):
+		return feedErr
+	case readErr != nil:
+		return readErr
+	case waitErr != nil:
+		return waitErr
+	}
+	return nil
+}
+
+// openURL returns the response to a successful GET of rawURL through client,
+// sent with header (which may be nil).
+func openURL(ctx context.Context, client *http.Client, rawURL string, header http.Header) (*http.Response, error) {
+	resp, _, err := openRange(ctx, client, rawURL, header, 0)
+	return resp, err
+}
+
+// openRange is like openURL but asks for the resource from offset bytes in,
+// returning the offset the body actually starts at (see rangeResponse).
+func openRange(ctx context.Context, client *http.Client, rawURL string, header http.Header,
+	offset int64) (*http.Response, int64, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	setRange(req, offset)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, err := rangeResponse(rawURL, resp, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, start, nil
+}
+
+// setRange asks for the resource from offset bytes in, if offset > 0.
+func setRange(req *http.Request, offset int64) {
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+}
+
+// rangeResponse checks the response to a GET sent with setRange(offset) and
+// returns the offset its body starts at: 0 if the server sent the whole
+// resource. A range starting past the end leaves an empty body.
+func rangeResponse(what string, resp *http.Response, offset int64) (int64, error) {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return 0, nil
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		var start int64
+		contentRange := resp.Header.Get("Content-Range")
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-", &start); err != nil || start != offset {
+			resp.Body.Close()
+			return 0, fmt.Errorf("GET %s: unexpected Content-Range %q", what, contentRange)
+		}
+		return offset, nil
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		resp.Body = http.NoBody
+		return offset, nil
+	}
+	resp.Body.Close()
+	return 0, statusError(what, resp)
+}
+
+// statusError reports an unsuccessful response. Client errors other than
+// timeouts and rate limiting are permanent: retrying will not fix them.
+func statusError(what string, resp *http.Response) error {
+	err := fmt.Errorf("GET %s: %s", what, resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
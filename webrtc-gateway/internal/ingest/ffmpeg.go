@@ -2,9 +2,13 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os/exec"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,36 +22,145 @@ import (
 // Matches the WebRTC output frame duration for minimal burstiness.
 const chunkSize = 640
 
-// FFmpegURLSource ingests audio from a URL using ffmpeg, normalizing to
-// PCM s16le 16kHz mono and writing to a ring buffer.
+// errLiveStreamEnded reports a live stream the server closed. Unlike a file
+// it has no end of its own, so this is a dropped connection, to be retried.
+var errLiveStreamEnded = errors.New("live stream closed by the server")
+
+// FFmpegURLSource ingests audio from a URL, decoded by ffmpeg to PCM s16le
+// 16kHz mono and written to a ring buffer. http(s) URLs are fetched through
+// NewHTTPClient; other schemes (file, s3) supply their own opener.
+//
+// File-backed sources can be paused, resumed and seeked (see Seekable): the
+// decoder is stopped and restarted at the wanted position of the file.
 type FFmpegURLSource struct {
-	url    string
-	rb     *ringbuffer.RingBuffer
-	maxDur time.Duration
-	logger *zap.Logger
+	kind     string // source type reported in Status
+	url      string
+	open     opener
+	seekable bool                     // file-backed regardless of what open returns
+	finite   atomic.Bool              // the last http response had a length, i.e. is a file
+	live     atomic.Bool              // the last http response came from a streaming server
+	rate     atomic.Pointer[byteRate] // sniffed from the start of the source, if it could be
+	rb       *ringbuffer.RingBuffer
+	maxDur   time.Duration
+	logger   *zap.Logger
+	relay    Relay
+	meta     *metadataQueue
 
 	mu        sync.Mutex
 	state     string
 	lastError string
 	cancel    context.CancelFunc
-	cmd       *exec.Cmd
+
+	// Pause/seek control, guarded by mu. position is the media position the
+	// current decoder started at (or, while paused, will restart at), and
+	// decodedFrom the bytesRead count when it started.
+	position    time.Duration
+	decodedFrom int64
+	paused      bool
+	resume      chan struct{} // closed by Resume
+	restart     bool          // the decoder was stopped by Pause/Seek, not Stop
+	seekTo      *time.Duration
+	stopDecode  context.CancelFunc
 
 	bytesRead atomic.Int64
 }
 
+// opener opens a source's media from offset bytes in and returns the offset
+// the body actually starts at: 0 when it can only be read from the start.
+type opener func(ctx context.Context, offset int64) (io.ReadCloser, int64, error)
+
 // NewFFmpegURLSource creates a new ffmpeg-based URL ingest source.
 func NewFFmpegURLSource(sourceURL string, rb *ringbuffer.RingBuffer,
 	maxDurationSec int, logger *zap.Logger) *FFmpegURLSource {
 
+	client := NewHTTPClient()
+	f := newFFmpegSource(TypeURL, sourceURL, nil, false, rb, maxDurationSec, logger)
+	f.open = func(ctx context.Context, offset int64) (io.ReadCloser, int64, error) {
+		if err := ValidateURL(sourceURL); err != nil {
+			return nil, 0, permanent(err)
+		}
+		return f.openHTTP(ctx, client, offset)
+	}
+	return f
+}
+
+// openHTTP fetches the URL, from offset bytes in if the server supports range
+// requests. With a metadata handler set it asks ICY servers (internet radio)
+// to interleave stream metadata, which is stripped from the audio and reported
+// through the handler.
+func (f *FFmpegURLSource) openHTTP(ctx context.Context, client *http.Client, offset int64) (io.ReadCloser, int64, error) {
+	var header http.Header
+	if f.meta != nil {
+		header = http.Header{"Icy-Metadata": {"1"}}
+	}
+	resp, start, err := openRange(ctx, client, f.url, header, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if start > 0 {
+		// Only files are opened part way, and ranges carry no ICY metadata.
+		return resp.Body, start, nil
+	}
+	metaint, _ := strconv.Atoi(resp.Header.Get("Icy-Metaint"))
+	// A response of known length is a file (a podcast episode, say) rather
+	// than a live stream, so it can be paused and seeked.
+	f.finite.Store(resp.ContentLength > 0 && metaint <= 0)
+	f.live.Store(isLiveResponse(resp))
+	if f.meta == nil || metaint <= 0 {
+		return resp.Body, 0, nil
+	}
+	bitrate := parseICYBitrate(resp.Header.Get("Icy-Br"))
+	r := newICYReader(resp.Body, metaint, func(title string, audioOffset int64) {
+		f.meta.push(icyMetadata(title), icyPCMPosition(audioOffset, bitrate))
+	})
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, resp.Body}, 0, nil
+}
+
+// isLiveResponse reports whether resp is from a live stream rather than a file:
+// an ICY (SHOUTcast/Icecast) server, or the stream-only AAC+ content type. A
+// missing length alone doesn't tell, as files may be sent chunked.
+func isLiveResponse(resp *http.Response) bool {
+	for k := range resp.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "icy-") || strings.HasPrefix(k, "ice-") {
+			return true
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "audio/aacp"
+}
+
+// newFFmpegSource creates an ffmpeg-decoded source of the given type reading
+// from whatever open returns. seekable marks sources that are always files.
+func newFFmpegSource(kind, sourceURL string, open opener,
+	seekable bool, rb *ringbuffer.RingBuffer, maxDurationSec int, logger *zap.Logger) *FFmpegURLSource {
+
 	return &FFmpegURLSource{
-		url:    sourceURL,
-		rb:     rb,
-		maxDur: time.Duration(maxDurationSec) * time.Second,
-		logger: logger.With(zap.String("ingestURL", sourceURL)),
-		state:  StateStopped,
+		kind:     kind,
+		url:      sourceURL,
+		open:     open,
+		seekable: seekable,
+		rb:       rb,
+		maxDur:   time.Duration(maxDurationSec) * time.Second,
+		logger:   logger.With(zap.String("ingestURL", sourceURL)),
+		state:    StateStopped,
 	}
 }
 
+// SetRelay plays the ingested audio live through r as well as buffering it.
+// The source is then read at real time. Call before Start.
+func (f *FFmpegURLSource) SetRelay(r Relay) {
+	f.relay = r
+}
+
+// SetMetadataHandler reports ICY stream titles to fn as the audio they apply
+// to reaches the ring buffer. Call before Start.
+func (f *FFmpegURLSource) SetMetadataHandler(fn func(Metadata)) {
+	f.meta = newMetadataQueue(fn)
+}
+
 // Start begins ingesting audio. Blocks until the source ends, ctx is cancelled, or Stop is called.
 func (f *FFmpegURLSource) Start(ctx context.Context) error {
 	f.mu.Lock()
@@ -55,9 +168,18 @@ func (f *FFmpegURLSource) Start(ctx context.Context) error {
 		f.mu.Unlock()
 		return fmt.Errorf("ingest already running")
 	}
+	// A file restarted after failing (by ReconnectingSource) continues where
+	// it failed; anything else starts from the beginning.
+	if f.state != StateError || !f.isSeekable() {
+		f.position = 0
+	}
 	f.state = StateStarting
 	f.lastError = ""
 	f.bytesRead.Store(0)
+	f.paused, f.seekTo = false, nil
+	if f.meta != nil {
+		f.meta.reset()
+	}
 
 	ingestCtx, cancel := context.WithCancel(ctx)
 	if f.maxDur > 0 {
@@ -68,45 +190,54 @@ func (f *FFmpegURLSource) Start(ctx context.Context) error {
 
 	defer cancel()
 
-	args := []string{
-		"-nostdin",
-		"-hide_banner", "-loglevel", "error",
-		"-reconnect", "1",
-		"-reconnect_streamed", "1",
-		"-reconnect_delay_max", "5",
-		"-i", f.url,
-		"-vn",
-		"-ac", "1",
-		"-ar", "16000",
-		"-f", "s16le",
-		"pipe:1",
-	}
-
-	cmd := exec.CommandContext(ingestCtx, "ffmpeg", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		f.setError(fmt.Sprintf("stdout pipe: %v", err))
-		return err
-	}
+	// Audio is timestamped from the media position, so the ring buffer
+	// timeline jumps where a seek took effect and is unbroken across a pause.
+	var origin time.Time
+	var err error
+	for {
+		start, ok := f.waitResumed(ingestCtx)
+		if !ok {
+			break
+		}
+		decodeCtx, stopDecode := context.WithCancel(ingestCtx)
+		f.mu.Lock()
+		f.stopDecode = stopDecode
+		f.restart = false
+		f.decodedFrom = f.bytesRead.Load()
+		f.mu.Unlock()
 
-	if err := cmd.Start(); err != nil {
-		f.setError(fmt.Sprintf("ffmpeg start: %v", err))
-		return err
+		var body io.ReadCloser
+		var skip time.Duration
+		body, skip, err = f.openAt(decodeCtx, start)
+		if err == nil {
+			err = decode(decodeCtx, f.feed(body), f.setRunning, pcmSink{
+				rb:        f.rb,
+				relay:     f.relay,
+				bytesRead: &f.bytesRead,
+				meta:      f.meta,
+				logger:    f.logger,
+				origin:    &origin,
+				start:     start,
+				skip:      skip,
+			})
+			body.Close()
+		}
+		stopDecode()
+
+		f.mu.Lock()
+		f.stopDecode = nil
+		f.position = start + ringbuffer.BytesDuration(int(f.bytesRead.Load()-f.decodedFrom))
+		f.decodedFrom = f.bytesRead.Load()
+		restart := f.restart && ingestCtx.Err() == nil
+		if restart && f.seekTo != nil {
+			f.position, f.seekTo = *f.seekTo, nil
+		}
+		f.mu.Unlock()
+		if !restart {
+			break
+		}
 	}
 
-	f.mu.Lock()
-	f.cmd = cmd
-	f.state = StateRunning
-	f.mu.Unlock()
-
-	f.logger.Info("ingest started")
-
-	// Read PCM from stdout and write to ring buffer
-	readErr := f.readLoop(ingestCtx, stdout)
-
-	// Wait for ffmpeg to exit
-	waitErr := cmd.Wait()
-
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -116,17 +247,11 @@ func (f *FFmpegURLSource) Start(ctx context.Context) error {
 		return nil
 	}
 
-	if readErr != nil || waitErr != nil {
-		errMsg := ""
-		if readErr != nil {
-			errMsg = readErr.Error()
-		} else if waitErr != nil {
-			errMsg = waitErr.Error()
-		}
+	if err != nil {
 		f.state = StateError
-		f.lastError = errMsg
-		f.logger.Warn("ingest error", zap.String("error", errMsg))
-		return fmt.Errorf("ingest failed: %s", errMsg)
+		f.lastError = err.Error()
+		f.logger.Warn("ingest error", zap.Error(err))
+		return fmt.Errorf("ingest failed: %w", err)
 	}
 
 	// Normal EOF (file ended)
@@ -136,11 +261,23 @@ func (f *FFmpegURLSource) Start(ctx context.Context) error {
 	return nil
 }
 
-func (f *FFmpegURLSource) readLoop(ctx context.Context, r io.Reader) error {
+// readPCM copies decoded PCM from r into the sink's ring buffer (and relay,
+// when set) until EOF or ctx ends, counting bytes in bytesRead and releasing
+// metadata as the audio it applies to arrives.
+func readPCM(ctx context.Context, r io.Reader, sink pcmSink) error {
+	rb, relay, bytesRead, logger := sink.rb, sink.relay, sink.bytesRead, sink.logger
 	buf := make([]byte, chunkSize)
-	capSec := f.rb.CapacitySeconds()
+	capSec := rb.CapacitySeconds()
 	lastLog := time.Now()
 
+	// Audio is timestamped by its position in the source, anchored at the
+	// first read: live streams track real time, files as if played from there.
+	origin := sink.origin
+	if origin == nil {
+		origin = new(time.Time)
+	}
+	var written int
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -150,20 +287,32 @@ func (f *FFmpegURLSource) readLoop(ctx context.Context, r io.Reader) error {
 
 		n, err := r.Read(buf)
 		if n > 0 {
-			f.rb.Write(buf[:n])
-			f.bytesRead.Add(int64(n))
+			if origin.IsZero() {
+				*origin = time.Now().Add(-sink.start)
+			}
+			rb.WriteAt(buf[:n], origin.Add(sink.start+ringbuffer.BytesDuration(written)))
+			written += n
+			bytesRead.Add(int64(n))
+			if sink.meta != nil {
+				sink.meta.release(bytesRead.Load(), rb)
+			}
 
 			// Periodic progress log every 5 seconds
 			if time.Since(lastLog) >= 5*time.Second {
-				f.logger.Info("ingest progress",
-					zap.Float64("bufferedSec", f.rb.Available()),
-					zap.Int64("bytesRead", f.bytesRead.Load()))
+				logger.Info("ingest progress",
+					zap.Float64("bufferedSec", rb.Available()),
+					zap.Int64("bytesRead", bytesRead.Load()))
 				lastLog = time.Now()
 			}
 
-			// Fill-then-realtime throttling: once buffer is full,
-			// pace writes at ~1x realtime to avoid wasting CPU on file URLs.
-			if f.rb.Available() >= capSec {
+			if relay != nil {
+				// The relay paces reads at real time.
+				if err := relay.Write(ctx, buf[:n]); err != nil {
+					return nil // ctx done
+				}
+			} else if rb.Available() >= capSec {
+				// Fill-then-realtime throttling: once buffer is full,
+				// pace writes at ~1x realtime to avoid wasting CPU on file URLs.
 				// 640 bytes = 20ms of audio at 16kHz s16le mono
 				sleepMs := float64(n) / float64(ringbuffer.BytesPerSecond) * 1000
 				time.Sleep(time.Duration(sleepMs) * time.Millisecond)
@@ -178,6 +327,154 @@ func (f *FFmpegURLSource) readLoop(ctx context.Context, r io.Reader) error {
 	}
 }
 
+// openAt opens the source to decode from media position pos. Once the byte
+// rate is known (see sniffByteRate) it is opened near pos, by a range request
+// or a file seek, and skip is 0. Otherwise, or if the source can only be read
+// from the start, skip is pos: the decoder must decode up to pos and discard
+// it, which for a long file can take a while.
+func (f *FFmpegURLSource) openAt(ctx context.Context, pos time.Duration) (body io.ReadCloser, skip time.Duration, err error) {
+	var offset int64
+	rate := f.rate.Load()
+	if pos > 0 && rate != nil {
+		offset = rate.offset(pos)
+	}
+	body, start, err := f.open(ctx, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if start > 0 {
+		return body, 0, nil
+	}
+	if rate == nil {
+		body = &sniffReader{ReadCloser: body, report: func(r byteRate) { f.rate.Store(&r) }}
+	}
+	return body, pos, nil
+}
+
+// feed returns a decoder feed streaming body. The clean end of a live stream
+// (see isLiveResponse) is reported as errLiveStreamEnded; that of anything
+// else, a file served without a length included, is the end of the ingest.
+func (f *FFmpegURLSource) feed(body io.Reader) func(context.Context, io.Writer) error {
+	return func(ctx context.Context, w io.Writer) error {
+		if _, err := io.Copy(w, body); err != nil {
+			return err
+		}
+		if f.live.Load() {
+			return errLiveStreamEnded
+		}
+		return nil
+	}
+}
+
+func (f *FFmpegURLSource) setRunning() {
+	f.mu.Lock()
+	f.state = StateRunning
+	f.mu.Unlock()
+	f.logger.Info("ingest started")
+}
+
+// waitResumed blocks while the source is paused and returns the position to
+// decode from, or false if ctx ends first.
+func (f *FFmpegURLSource) waitResumed(ctx context.Context) (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.paused {
+		f.state = StatePaused
+		resume := f.resume
+		f.mu.Unlock()
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		}
+		f.mu.Lock()
+		if ctx.Err() != nil {
+			return 0, false
+		}
+	}
+	return f.position, ctx.Err() == nil
+}
+
+func (f *FFmpegURLSource) isSeekable() bool {
+	return f.seekable || f.finite.Load()
+}
+
+// checkControl reports why the source cannot be paused or seeked now. Called
+// with f.mu held.
+func (f *FFmpegURLSource) checkControl() error {
+	if !f.isSeekable() {
+		return ErrNotSeekable
+	}
+	if f.state != StateStarting && f.state != StateRunning && f.state != StatePaused {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// Pause stops ingesting, leaving the ring buffer as it is, until Resume. The
+// max ingest duration keeps running while paused.
+func (f *FFmpegURLSource) Pause() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.checkControl(); err != nil {
+		return err
+	}
+	if f.paused {
+		return nil
+	}
+	f.paused = true
+	f.resume = make(chan struct{})
+	f.interruptLocked()
+	f.logger.Info("ingest paused")
+	return nil
+}
+
+// Resume continues a paused ingest from where it was paused or seeked to.
+func (f *FFmpegURLSource) Resume() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.checkControl(); err != nil {
+		return err
+	}
+	if !f.paused {
+		return nil
+	}
+	f.paused = false
+	close(f.resume)
+	f.logger.Info("ingest resumed", zap.Duration("position", f.position))
+	return nil
+}
+
+// Seek continues ingesting from pos into the file. A paused source stays
+// paused at the new position.
+func (f *FFmpegURLSource) Seek(pos time.Duration) error {
+	if pos < 0 {
+		return fmt.Errorf("seek position must not be negative")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.checkControl(); err != nil {
+		return err
+	}
+	if f.stopDecode == nil {
+		// Paused, or between decoders: picked up by the next one.
+		f.position = pos
+		return nil
+	}
+	f.seekTo = &pos
+	f.interruptLocked()
+	f.logger.Info("ingest seek", zap.Duration("position", pos))
+	return nil
+}
+
+// interruptLocked stops the running decoder so Start restarts it. Called with
+// f.mu held.
+func (f *FFmpegURLSource) interruptLocked() {
+	if f.stopDecode != nil {
+		f.restart = true
+		f.stopDecode()
+	}
+}
+
 // Stop terminates the ingest. Idempotent.
 func (f *FFmpegURLSource) Stop() {
 	f.mu.Lock()
@@ -194,15 +491,29 @@ func (f *FFmpegURLSource) Status() Status {
 	f.mu.Lock()
 	state := f.state
 	lastErr := f.lastError
+	pos := f.position
+	if f.stopDecode != nil && !f.restart {
+		pos += ringbuffer.BytesDuration(int(f.bytesRead.Load() - f.decodedFrom))
+	} else if f.seekTo != nil {
+		pos = *f.seekTo
+	}
 	f.mu.Unlock()
 
-	return Status{
+	st := Status{
+		Type:            f.kind,
 		State:           state,
 		SourceURL:       f.url,
 		SecondsBuffered: f.rb.Available(),
 		BytesRead:       f.bytesRead.Load(),
 		LastError:       lastErr,
+		Relaying:        f.relay != nil,
+		Metadata:        f.meta.Current(),
+	}
+	if f.isSeekable() {
+		st.Seekable = true
+		st.PositionSec = pos.Seconds()
 	}
+	return st
 }
 
 func (f *FFmpegURLSource) setError(msg string) {
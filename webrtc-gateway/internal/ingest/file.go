@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fileFactory serves files from the media library rooted at root. Requests
+// name a path relative to the root, either as a file: URL (file:talks/a.mp3,
+// file:///talks/a.mp3) or, with type "file", as a bare path.
+func fileFactory(root string) Factory {
+	return Factory{
+		Schemes: []string{"file"},
+		Validate: func(req Request) error {
+			_, err := libraryPath(root, req.URL)
+			return err
+		},
+		New: func(req Request, env Env) Source {
+			rel, _ := libraryRelPath(req.URL)
+			open := func(_ context.Context, offset int64) (io.ReadCloser, int64, error) {
+				// Resolved again: the library may have changed since validation.
+				p, err := libraryPath(root, req.URL)
+				if err != nil {
+					return nil, 0, permanent(err)
+				}
+				f, err := os.Open(p)
+				if err != nil {
+					return nil, 0, permanent(err)
+				}
+				if _, err := f.Seek(offset, io.SeekStart); err != nil {
+					f.Close()
+					return nil, 0, err
+				}
+				return f, offset, nil
+			}
+			return newFFmpegSource(TypeFile, "file:"+rel, open, true,
+				env.RingBuffer, env.MaxDurationSec, env.Logger)
+		},
+	}
+}
+
+// libraryRelPath extracts the library-relative, slash-separated path a request names.
+func libraryRelPath(ref string) (string, error) {
+	p := ref
+	if strings.HasPrefix(ref, "file:") {
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL: %w", err)
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return "", fmt.Errorf("file URLs cannot name a host")
+		}
+		p = u.Path
+		if u.Opaque != "" {
+			if p, err = url.PathUnescape(u.Opaque); err != nil {
+				return "", fmt.Errorf("invalid file URL: %w", err)
+			}
+		}
+	}
+	if strings.ContainsRune(p, 0) || strings.Contains(p, `\`) {
+		return "", fmt.Errorf("invalid media library path")
+	}
+	p = strings.TrimLeft(path.Clean("/"+p), "/")
+	if p == "" {
+		return "", fmt.Errorf("media library path is required")
+	}
+	return p, nil
+}
+
+// libraryPath resolves a request to a regular file inside the library root.
+// Symlinks are followed, but only to targets that are still inside the root.
+func libraryPath(root, ref string) (string, error) {
+	rel, err := libraryRelPath(ref)
+	if err != nil {
+		return "", err
+	}
+	rootDir, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("media library unavailable")
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(rootDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", fmt.Errorf("media file %q not found", rel)
+	}
+	if r, err := filepath.Rel(rootDir, full); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("media file %q is outside the library", rel)
+	}
+	info, err := os.Stat(full)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("media file %q is not a regular file", rel)
+	}
+	return full, nil
+}
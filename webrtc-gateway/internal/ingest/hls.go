@@ -0,0 +1,639 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+const (
+	// maxPlaylistBytes bounds a fetched playlist.
+	maxPlaylistBytes = 1 << 20
+	// liveStartSegments is how far back from the live edge a live playlist is
+	// joined, as recommended by RFC 8216 §6.3.3.
+	liveStartSegments = 3
+	// hlsFetchTimeout bounds a single playlist or segment request.
+	hlsFetchTimeout = 30 * time.Second
+)
+
+// HLSOptions selects what to play from a master playlist.
+type HLSOptions struct {
+	// Language prefers an audio rendition in this language (BCP-47 prefix match).
+	Language string
+	// MaxBandwidth excludes variants above this many bits/s (0 = no limit).
+	MaxBandwidth int
+}
+
+// IsHLSURL reports whether rawURL looks like an HLS playlist (.m3u8).
+func IsHLSURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(path.Ext(u.Path), ".m3u8")
+}
+
+// HLSSource ingests an HLS stream. It follows the master playlist to one audio
+// rendition, fetches its segments itself (refreshing live playlists) and feeds
+// them to ffmpeg for decoding to PCM s16le 16kHz mono. Every playlist and
+// segment URL is checked with ValidateURL before it is fetched, and every
+// connection by the NewHTTPClient dialer.
+type HLSSource struct {
+	url    string
+	opts   HLSOptions
+	rb     *ringbuffer.RingBuffer
+	maxDur time.Duration
+	logger *zap.Logger
+	relay  Relay
+	meta   *metadataQueue
+	client *http.Client
+	// validate vets every URL before it is fetched (ValidateURL outside tests).
+	validate func(string) error
+
+	mu        sync.Mutex
+	state     string
+	lastError string
+	cancel    context.CancelFunc
+	live      bool
+	edge      time.Duration // media time of the live edge since the first segment fetched
+
+	bytesRead atomic.Int64
+	segments  atomic.Int64
+}
+
+// NewHLSSource creates an HLS ingest source for a master or media playlist URL.
+func NewHLSSource(playlistURL string, opts HLSOptions, rb *ringbuffer.RingBuffer,
+	maxDurationSec int, logger *zap.Logger) *HLSSource {
+
+	client := NewHTTPClient()
+	client.Timeout = hlsFetchTimeout
+	return &HLSSource{
+		url:      playlistURL,
+		opts:     opts,
+		rb:       rb,
+		maxDur:   time.Duration(maxDurationSec) * time.Second,
+		logger:   logger.With(zap.String("ingestURL", playlistURL)),
+		client:   client,
+		validate: ValidateURL,
+		state:    StateStopped,
+	}
+}
+
+// SetRelay plays the ingested audio live through r as well as buffering it.
+// Call before Start.
+func (h *HLSSource) SetRelay(r Relay) {
+	h.relay = r
+}
+
+// SetMetadataHandler reports titles and artists from the segments' ID3 tags to
+// fn as the audio they apply to reaches the ring buffer. Call before Start.
+func (h *HLSSource) SetMetadataHandler(fn func(Metadata)) {
+	h.meta = newMetadataQueue(fn)
+}
+
+// Start begins ingesting audio. Blocks until the stream ends, ctx is cancelled, or Stop is called.
+func (h *HLSSource) Start(ctx context.Context) error {
+	h.mu.Lock()
+	if h.state == StateRunning || h.state == StateStarting {
+		h.mu.Unlock()
+		return fmt.Errorf("ingest already running")
+	}
+	h.state = StateStarting
+	h.lastError = ""
+	h.live, h.edge = false, 0
+	h.bytesRead.Store(0)
+	h.segments.Store(0)
+	if h.meta != nil {
+		h.meta.reset()
+	}
+
+	ingestCtx, cancel := context.WithCancel(ctx)
+	if h.maxDur > 0 {
+		ingestCtx, cancel = context.WithTimeout(ctx, h.maxDur)
+	}
+	h.cancel = cancel
+	h.mu.Unlock()
+
+	defer cancel()
+
+	err := decode(ingestCtx, h.fetch, h.setRunning, pcmSink{
+		rb:        h.rb,
+		relay:     h.relay,
+		bytesRead: &h.bytesRead,
+		meta:      h.meta,
+		logger:    h.logger,
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ingestCtx.Err() != nil {
+		h.state = StateStopped
+		h.logger.Info("hls ingest stopped", zap.Int64("segments", h.segments.Load()))
+		return nil
+	}
+
+	if err != nil {
+		h.state = StateError
+		h.lastError = err.Error()
+		h.logger.Warn("hls ingest error", zap.Error(err))
+		return fmt.Errorf("ingest failed: %w", err)
+	}
+
+	h.state = StateStopped
+	h.logger.Info("hls ingest completed (playlist ended)",
+		zap.Int64("segments", h.segments.Load()),
+		zap.Int64("bytesRead", h.bytesRead.Load()))
+	return nil
+}
+
+// fetch resolves the media playlist and writes its segments to w in order,
+// refreshing a live playlist until it ends or ctx is cancelled.
+func (h *HLSSource) fetch(ctx context.Context, w io.Writer) error {
+	mediaURL, pl, err := h.resolveMediaPlaylist(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := int64(-1) // media sequence number of the next segment to fetch
+	var mapURI string // initialization section already written
+	var fetched time.Duration
+
+	loadedAt := time.Now()
+	for {
+		if pl == nil {
+			loadedAt = time.Now()
+			if pl, err = h.loadPlaylist(ctx, mediaURL); err != nil {
+				return err
+			}
+		}
+		if pl.master {
+			return fmt.Errorf("expected a media playlist at %s", mediaURL)
+		}
+		if pl.encrypted {
+			return permanent(errors.New("encrypted HLS segments are not supported"))
+		}
+
+		if next < 0 && len(pl.segments) > 0 {
+			start := 0
+			if !pl.endList {
+				start = max(0, len(pl.segments)-liveStartSegments)
+			}
+			next = pl.segments[start].sequence
+		}
+		if len(pl.segments) > 0 && pl.segments[0].sequence > next {
+			h.logger.Warn("hls segments expired before they were fetched",
+				zap.Int64("skipped", pl.segments[0].sequence-next))
+			next = pl.segments[0].sequence
+		}
+
+		var pending time.Duration
+		for _, seg := range pl.segments {
+			if seg.sequence >= next {
+				pending += seg.duration
+			}
+		}
+		h.mu.Lock()
+		h.live = !pl.endList
+		h.edge = fetched + pending
+		h.mu.Unlock()
+
+		changed := false
+		for _, seg := range pl.segments {
+			if seg.sequence < next {
+				continue
+			}
+			if seg.mapURI != "" && seg.mapURI != mapURI {
+				if err := h.copyURL(ctx, seg.mapURI, w); err != nil {
+					return fmt.Errorf("hls init section: %w", err)
+				}
+				mapURI = seg.mapURI
+			}
+			if err := h.copySegment(ctx, seg.uri, w, fetched); err != nil {
+				return fmt.Errorf("hls segment %d: %w", seg.sequence, err)
+			}
+			next = seg.sequence + 1
+			fetched += seg.duration
+			h.segments.Add(1)
+			changed = true
+		}
+
+		if pl.endList {
+			return nil
+		}
+
+		// RFC 8216 §6.3.4: reload after a target duration, or half of one if
+		// the playlist had nothing new.
+		reload := pl.targetDuration
+		if !changed {
+			reload /= 2
+		}
+		timer := time.NewTimer(time.Until(loadedAt.Add(reload)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		pl = nil
+	}
+}
+
+// resolveMediaPlaylist returns the URL of the media playlist to play, choosing
+// a rendition if h.url is a master playlist. When h.url is itself the media
+// playlist, it is returned as loaded.
+func (h *HLSSource) resolveMediaPlaylist(ctx context.Context) (string, *hlsPlaylist, error) {
+	pl, err := h.loadPlaylist(ctx, h.url)
+	if err != nil {
+		return "", nil, err
+	}
+	if !pl.master {
+		return h.url, pl, nil
+	}
+	mediaURL, desc, err := pl.selectMedia(h.opts)
+	if err != nil {
+		return "", nil, err
+	}
+	h.logger.Info("hls rendition selected", zap.String("rendition", desc), zap.String("playlist", mediaURL))
+	return mediaURL, nil, nil
+}
+
+func (h *HLSSource) loadPlaylist(ctx context.Context, playlistURL string) (*hlsPlaylist, error) {
+	body, err := h.get(ctx, playlistURL)
+	if err != nil {
+		return nil, fmt.Errorf("hls playlist: %w", err)
+	}
+	defer body.Close()
+
+	base, _ := url.Parse(playlistURL)
+	pl, err := parsePlaylist(base, io.LimitReader(body, maxPlaylistBytes))
+	if err != nil {
+		return nil, fmt.Errorf("hls playlist %s: %w", playlistURL, err)
+	}
+	return pl, nil
+}
+
+func (h *HLSSource) copyURL(ctx context.Context, rawURL string, w io.Writer) error {
+	body, err := h.get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// copySegment writes a media segment to w, first checking the start of it for
+// ID3 tags when metadata is reported. mediaPos is where the segment starts in
+// the audio fetched so far.
+func (h *HLSSource) copySegment(ctx context.Context, rawURL string, w io.Writer, mediaPos time.Duration) error {
+	if h.meta == nil {
+		return h.copyURL(ctx, rawURL, w)
+	}
+	body, err := h.get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	head, err := io.ReadAll(io.LimitReader(body, maxID3Scan))
+	if err != nil {
+		return err
+	}
+	if m, ok := segmentMetadata(head); ok {
+		h.meta.push(m, int64(mediaPos.Seconds()*ringbuffer.BytesPerSecond))
+	}
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// get validates rawURL and returns the body of a successful GET.
+func (h *HLSSource) get(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	if err := h.validate(rawURL); err != nil {
+		return nil, permanent(err)
+	}
+	resp, err := openURL(ctx, h.client, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (h *HLSSource) setRunning() {
+	h.mu.Lock()
+	h.state = StateRunning
+	h.mu.Unlock()
+	h.logger.Info("hls ingest started")
+}
+
+// Stop terminates the ingest. Idempotent.
+func (h *HLSSource) Stop() {
+	h.mu.Lock()
+	cancel := h.cancel
+	h.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Status returns a snapshot of current ingest state. For live playlists
+// LiveLatencySec is how far the decoded audio trails the newest segment.
+func (h *HLSSource) Status() Status {
+	h.mu.Lock()
+	state := h.state
+	lastErr := h.lastError
+	live := h.live
+	edge := h.edge
+	h.mu.Unlock()
+
+	st := Status{
+		Type:            TypeHLS,
+		State:           state,
+		SourceURL:       h.url,
+		SecondsBuffered: h.rb.Available(),
+		BytesRead:       h.bytesRead.Load(),
+		LastError:       lastErr,
+		Relaying:        h.relay != nil,
+		SegmentsFetched: h.segments.Load(),
+		Metadata:        h.meta.Current(),
+	}
+	if live && state == StateRunning {
+		played := ringbuffer.BytesDuration(int(st.BytesRead))
+		st.LiveLatencySec = max(0, (edge - played).Seconds())
+	}
+	return st
+}
+
+func (h *HLSSource) setError(msg string) {
+	h.mu.Lock()
+	h.state = StateError
+	h.lastError = msg
+	h.mu.Unlock()
+}
+
+// hlsPlaylist is the subset of an M3U8 playlist (RFC 8216) needed for audio.
+type hlsPlaylist struct {
+	master bool
+
+	// Master playlist.
+	variants   []hlsVariant
+	renditions []hlsRendition // EXT-X-MEDIA TYPE=AUDIO
+
+	// Media playlist.
+	targetDuration time.Duration
+	segments       []hlsSegment
+	endList        bool
+	encrypted      bool
+}
+
+type hlsVariant struct {
+	uri        string
+	bandwidth  int
+	codecs     string
+	audioGroup string
+}
+
+type hlsRendition struct {
+	groupID   string
+	name      string
+	language  string
+	isDefault bool
+	uri       string
+}
+
+type hlsSegment struct {
+	uri      string
+	mapURI   string // EXT-X-MAP initialization section, if any
+	duration time.Duration
+	sequence int64
+}
+
+// parsePlaylist parses a master or media playlist, resolving URIs against base.
+func parsePlaylist(base *url.URL, r io.Reader) (*hlsPlaylist, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxPlaylistBytes)
+
+	pl := &hlsPlaylist{}
+	resolve := func(ref string) (string, error) {
+		u, err := base.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid URI %q: %w", ref, err)
+		}
+		return u.String(), nil
+	}
+
+	first := true
+	sequence := int64(0)
+	var segDuration time.Duration
+	var mapURI string
+	var pendingVariant *hlsVariant
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if first {
+			if line != "#EXTM3U" {
+				return nil, errors.New("missing #EXTM3U header")
+			}
+			first = false
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		tag, value, _ := strings.Cut(line, ":")
+		switch tag {
+		case "#EXT-X-STREAM-INF":
+			attrs := parseAttributes(value)
+			bw, _ := strconv.Atoi(attrs["BANDWIDTH"])
+			pl.master = true
+			pendingVariant = &hlsVariant{bandwidth: bw, codecs: attrs["CODECS"], audioGroup: attrs["AUDIO"]}
+		case "#EXT-X-MEDIA":
+			attrs := parseAttributes(value)
+			pl.master = true
+			if attrs["TYPE"] != "AUDIO" || attrs["URI"] == "" {
+				continue
+			}
+			uri, err := resolve(attrs["URI"])
+			if err != nil {
+				return nil, err
+			}
+			pl.renditions = append(pl.renditions, hlsRendition{
+				groupID:   attrs["GROUP-ID"],
+				name:      attrs["NAME"],
+				language:  attrs["LANGUAGE"],
+				isDefault: attrs["DEFAULT"] == "YES",
+				uri:       uri,
+			})
+		case "#EXT-X-TARGETDURATION":
+			secs, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EXT-X-TARGETDURATION %q", value)
+			}
+			pl.targetDuration = time.Duration(secs) * time.Second
+		case "#EXT-X-MEDIA-SEQUENCE":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EXT-X-MEDIA-SEQUENCE %q", value)
+			}
+			sequence = n
+		case "#EXTINF":
+			durStr, _, _ := strings.Cut(value, ",")
+			secs, err := strconv.ParseFloat(durStr, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EXTINF %q", value)
+			}
+			segDuration = time.Duration(secs * float64(time.Second))
+		case "#EXT-X-MAP":
+			uri, err := resolve(parseAttributes(value)["URI"])
+			if err != nil {
+				return nil, err
+			}
+			mapURI = uri
+		case "#EXT-X-KEY":
+			if method := parseAttributes(value)["METHOD"]; method != "" && method != "NONE" {
+				pl.encrypted = true
+			}
+		case "#EXT-X-ENDLIST":
+			pl.endList = true
+		default:
+			if strings.HasPrefix(line, "#") {
+				continue // comment or unsupported tag
+			}
+			uri, err := resolve(line)
+			if err != nil {
+				return nil, err
+			}
+			if pendingVariant != nil {
+				pendingVariant.uri = uri
+				pl.variants = append(pl.variants, *pendingVariant)
+				pendingVariant = nil
+				continue
+			}
+			pl.segments = append(pl.segments, hlsSegment{
+				uri:      uri,
+				mapURI:   mapURI,
+				duration: segDuration,
+				sequence: sequence,
+			})
+			sequence++
+			segDuration = 0
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if first {
+		return nil, errors.New("empty playlist")
+	}
+	if !pl.master && pl.targetDuration <= 0 {
+		return nil, errors.New("media playlist without EXT-X-TARGETDURATION")
+	}
+	return pl, nil
+}
+
+// selectMedia picks the media playlist to ingest from a master playlist and
+// describes the choice. A separate audio rendition is preferred (matching
+// opts.Language, else the default one); otherwise the best audio-only variant,
+// else the cheapest variant, within opts.MaxBandwidth.
+func (pl *hlsPlaylist) selectMedia(opts HLSOptions) (string, string, error) {
+	if len(pl.renditions) > 0 {
+		best := pl.renditions[0]
+		for _, r := range pl.renditions {
+			if opts.Language != "" && languageMatches(r.language, opts.Language) {
+				best = r
+				break
+			}
+			if r.isDefault && !best.isDefault {
+				best = r
+			}
+		}
+		return best.uri, fmt.Sprintf("audio %q (%s)", best.name, best.language), nil
+	}
+
+	var candidates []hlsVariant
+	for _, v := range pl.variants {
+		if opts.MaxBandwidth <= 0 || v.bandwidth <= opts.MaxBandwidth {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", "", errors.New("no HLS variant within the bandwidth limit")
+	}
+
+	// Audio-only variants sort first, by descending bandwidth; the rest by
+	// ascending bandwidth, since only their audio is used.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ai, aj := audioOnly(candidates[i].codecs), audioOnly(candidates[j].codecs)
+		if ai != aj {
+			return ai
+		}
+		if ai {
+			return candidates[i].bandwidth > candidates[j].bandwidth
+		}
+		return candidates[i].bandwidth < candidates[j].bandwidth
+	})
+	v := candidates[0]
+	return v.uri, fmt.Sprintf("variant %d bit/s %s", v.bandwidth, v.codecs), nil
+}
+
+// audioOnly reports whether an RFC 6381 CODECS list names only audio codecs.
+func audioOnly(codecs string) bool {
+	if codecs == "" {
+		return false
+	}
+	for _, c := range strings.Split(codecs, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		switch {
+		case strings.HasPrefix(c, "mp4a"), c == "mp3", c == "ac-3", c == "ec-3",
+			c == "opus", c == "flac":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func languageMatches(tag, want string) bool {
+	tag, want = strings.ToLower(tag), strings.ToLower(want)
+	return tag == want || strings.HasPrefix(tag, want+"-")
+}
+
+// parseAttributes parses an HLS attribute list (KEY=VALUE,KEY="quoted, value").
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if quoted, ok := strings.CutPrefix(rest, `"`); ok {
+			value, s, _ = strings.Cut(quoted, `"`)
+			s = strings.TrimPrefix(s, ",")
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.TrimSpace(key)] = value
+	}
+	return attrs
+}
@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// newTestHLSSource returns a source for a playlist on a local test server,
+// allowing loopback URLs but recording every URL that was vetted.
+func newTestHLSSource(playlistURL string, opts HLSOptions) (*HLSSource, *[]string) {
+	h := NewHLSSource(playlistURL, opts, ringbuffer.New(5), 0, zap.NewNop())
+	h.client = newHTTPClient(func(net.IP) error { return nil })
+	var mu sync.Mutex
+	var vetted []string
+	h.validate = func(u string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		vetted = append(vetted, u)
+		if strings.Contains(u, "169.254.169.254") {
+			return fmt.Errorf("URL resolves to private/reserved IP 169.254.169.254")
+		}
+		return nil
+	}
+	return h, &vetted
+}
+
+func TestParseAttributes(t *testing.T) {
+	attrs := parseAttributes(`BANDWIDTH=64000,CODECS="mp4a.40.2,avc1.4d401e",AUDIO="aud",NAME="A, B"`)
+	want := map[string]string{
+		"BANDWIDTH": "64000",
+		"CODECS":    "mp4a.40.2,avc1.4d401e",
+		"AUDIO":     "aud",
+		"NAME":      "A, B",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s = %q, want %q", k, attrs[k], v)
+		}
+	}
+}
+
+func TestSelectMedia(t *testing.T) {
+	base, _ := url.Parse("https://example.com/live/master.m3u8")
+	parse := func(body string) *hlsPlaylist {
+		pl, err := parsePlaylist(base, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pl
+	}
+
+	renditions := parse(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="a",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en/audio.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="a",NAME="Português",LANGUAGE="pt-BR",URI="pt/audio.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2000000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="a"
+video.m3u8
+`)
+	if got, _, _ := renditions.selectMedia(HLSOptions{}); got != "https://example.com/live/en/audio.m3u8" {
+		t.Errorf("default rendition: %s", got)
+	}
+	if got, _, _ := renditions.selectMedia(HLSOptions{Language: "pt"}); got != "https://example.com/live/pt/audio.m3u8" {
+		t.Errorf("language rendition: %s", got)
+	}
+
+	variants := parse(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS="avc1.4d401e,mp4a.40.2"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,CODECS="mp4a.40.2"
+audio-hi.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=64000,CODECS="mp4a.40.5"
+audio-lo.m3u8
+`)
+	if got, _, _ := variants.selectMedia(HLSOptions{}); got != "https://example.com/live/audio-hi.m3u8" {
+		t.Errorf("best audio-only variant: %s", got)
+	}
+	if got, _, _ := variants.selectMedia(HLSOptions{MaxBandwidth: 100000}); got != "https://example.com/live/audio-lo.m3u8" {
+		t.Errorf("bandwidth-capped variant: %s", got)
+	}
+}
+
+func TestHLSFetchVOD(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/master.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=64000,CODECS=\"mp4a.40.2\"\naudio/index.m3u8\n")
+		case "/audio/index.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MAP:URI=\"init.mp4\"\n"+
+				"#EXTINF:4.0,\nseg0.m4s\n#EXTINF:4.0,\nseg1.m4s\n#EXTINF:2.5,\nseg2.m4s\n#EXT-X-ENDLIST\n")
+		default:
+			fmt.Fprintf(w, "[%s]", strings.TrimPrefix(r.URL.Path, "/audio/"))
+		}
+	}))
+	defer srv.Close()
+
+	h, vetted := newTestHLSSource(srv.URL+"/master.m3u8", HLSOptions{})
+	var out bytes.Buffer
+	if err := h.fetch(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "[init.mp4][seg0.m4s][seg1.m4s][seg2.m4s]"; got != want {
+		t.Errorf("fetched %q, want %q", got, want)
+	}
+	if h.segments.Load() != 3 {
+		t.Errorf("segments fetched = %d, want 3", h.segments.Load())
+	}
+	// master, media playlist, init section and three segments
+	if len(*vetted) != 6 {
+		t.Errorf("validated %d URLs, want 6: %v", len(*vetted), *vetted)
+	}
+}
+
+func TestHLSFetchLiveRefresh(t *testing.T) {
+	var mu sync.Mutex
+	loads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/live.m3u8" {
+			fmt.Fprintf(w, "[%s]", strings.TrimPrefix(r.URL.Path, "/"))
+			return
+		}
+		mu.Lock()
+		loads++
+		n := loads
+		mu.Unlock()
+
+		// The window slides by one segment per reload; the second reload ends it.
+		var b strings.Builder
+		fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:%d\n", 10+n)
+		for seq := 10 + n; seq < 15+n; seq++ {
+			fmt.Fprintf(&b, "#EXTINF:1.0,\ns%d.ts\n", seq)
+		}
+		if n >= 2 {
+			b.WriteString("#EXT-X-ENDLIST\n")
+		}
+		fmt.Fprint(w, b.String())
+	}))
+	defer srv.Close()
+
+	h, _ := newTestHLSSource(srv.URL+"/live.m3u8", HLSOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	if err := h.fetch(ctx, &out); err != nil {
+		t.Fatal(err)
+	}
+	// Joined three segments from the live edge (13-15), then picked up 16.
+	if got, want := out.String(), "[s13.ts][s14.ts][s15.ts][s16.ts]"; got != want {
+		t.Errorf("fetched %q, want %q", got, want)
+	}
+}
+
+func TestHLSRejectsUnsafeSegment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\n"+
+			"http://169.254.169.254/latest/meta-data\n#EXT-X-ENDLIST\n")
+	}))
+	defer srv.Close()
+
+	h, _ := newTestHLSSource(srv.URL+"/index.m3u8", HLSOptions{})
+	err := h.fetch(context.Background(), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "private/reserved") {
+		t.Errorf("fetch returned %v, want an SSRF rejection", err)
+	}
+}
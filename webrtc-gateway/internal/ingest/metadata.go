@@ -0,0 +1,345 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// Metadata describes what a stream started playing, from ICY StreamTitle or
+// HLS ID3 tags.
+type Metadata struct {
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty"`
+	// StreamTitle is the raw ICY StreamTitle, when that was the source.
+	StreamTitle string `json:"streamTitle,omitempty"`
+	// Offset is the absolute ring buffer offset of the first audio it applies
+	// to, and At that audio's capture time.
+	Offset int       `json:"-"`
+	At     time.Time `json:"-"`
+}
+
+func (m Metadata) key() string {
+	return m.Artist + "\x00" + m.Title + "\x00" + m.StreamTitle
+}
+
+// metadataQueue carries metadata found in the fetched stream to the PCM reader.
+// Metadata is found before the decoder has produced the audio it applies to,
+// so each item waits until decoding reaches its position.
+type metadataQueue struct {
+	notify func(Metadata)
+
+	mu      sync.Mutex
+	pending []pendingMetadata
+	last    string    // key of the last item queued, to drop repeats
+	current *Metadata // last item released
+}
+
+type pendingMetadata struct {
+	m      Metadata
+	pcmPos int64 // decoded bytes before the audio it applies to; < 0 = now
+}
+
+func newMetadataQueue(notify func(Metadata)) *metadataQueue {
+	return &metadataQueue{notify: notify}
+}
+
+// reset drops pending items when the source restarts. Repeats of the last
+// item are still suppressed.
+func (q *metadataQueue) reset() {
+	q.mu.Lock()
+	q.pending = nil
+	q.mu.Unlock()
+}
+
+// push queues m to be released once pcmPos decoded bytes have been read.
+func (q *metadataQueue) push(m Metadata, pcmPos int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if k := m.key(); k != q.last {
+		q.last = k
+		q.pending = append(q.pending, pendingMetadata{m: m, pcmPos: pcmPos &^ 1})
+	}
+}
+
+// release reports every pending item whose position has been read, given the
+// total decoded bytes read so far (which end at rb's write position).
+func (q *metadataQueue) release(bytesRead int64, rb *ringbuffer.RingBuffer) {
+	q.mu.Lock()
+	var due []Metadata
+	for len(q.pending) > 0 && q.pending[0].pcmPos <= bytesRead {
+		p := q.pending[0]
+		q.pending = q.pending[1:]
+		back := int64(0)
+		if p.pcmPos >= 0 {
+			back = bytesRead - p.pcmPos
+		}
+		p.m.Offset = max(0, rb.Written()-int(back))
+		p.m.At = rb.TimeAt(p.m.Offset)
+		m := p.m
+		q.current = &m
+		due = append(due, m)
+	}
+	q.mu.Unlock()
+
+	for _, m := range due {
+		if q.notify != nil {
+			q.notify(m)
+		}
+	}
+}
+
+// Current returns the metadata in effect, or nil.
+func (q *metadataQueue) Current() *Metadata {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current == nil {
+		return nil
+	}
+	m := *q.current
+	return &m
+}
+
+// icyReader strips the metadata blocks an ICY (Shoutcast/Icecast) server
+// interleaves every metaint bytes of audio, passing each StreamTitle to
+// onTitle with the number of audio bytes that preceded it.
+type icyReader struct {
+	r       io.Reader
+	metaint int
+	left    int   // audio bytes until the next metadata block
+	audio   int64 // audio bytes passed through
+	onTitle func(title string, audioOffset int64)
+}
+
+func newICYReader(r io.Reader, metaint int, onTitle func(string, int64)) *icyReader {
+	return &icyReader{r: r, metaint: metaint, left: metaint, onTitle: onTitle}
+}
+
+func (ir *icyReader) Read(p []byte) (int, error) {
+	if ir.left == 0 {
+		if err := ir.readMetadata(); err != nil {
+			return 0, err
+		}
+		ir.left = ir.metaint
+	}
+	if len(p) > ir.left {
+		p = p[:ir.left]
+	}
+	n, err := ir.r.Read(p)
+	ir.left -= n
+	ir.audio += int64(n)
+	return n, err
+}
+
+// readMetadata consumes one block: a length byte (in 16-byte units) followed
+// by e.g. "StreamTitle='Artist - Title';StreamUrl='...';", NUL-padded.
+func (ir *icyReader) readMetadata() error {
+	var size [1]byte
+	if _, err := io.ReadFull(ir.r, size[:]); err != nil {
+		return err
+	}
+	if size[0] == 0 {
+		return nil // unchanged
+	}
+	block := make([]byte, int(size[0])*16)
+	if _, err := io.ReadFull(ir.r, block); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if title, ok := parseStreamTitle(block); ok {
+		ir.onTitle(title, ir.audio)
+	}
+	return nil
+}
+
+func parseStreamTitle(block []byte) (string, bool) {
+	s := string(bytes.TrimRight(block, "\x00"))
+	const key = "StreamTitle='"
+	i := strings.Index(s, key)
+	if i < 0 {
+		return "", false
+	}
+	s = s[i+len(key):]
+	// The value is not escaped, so a title may itself contain "'".
+	end := strings.Index(s, "';")
+	if end < 0 {
+		if end = strings.LastIndex(s, "'"); end < 0 {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(latin1ToUTF8(s[:end])), true
+}
+
+// icyMetadata splits an ICY "Artist - Title" stream title.
+func icyMetadata(streamTitle string) Metadata {
+	m := Metadata{Title: streamTitle, StreamTitle: streamTitle}
+	if artist, title, ok := strings.Cut(streamTitle, " - "); ok {
+		m.Artist, m.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+	}
+	return m
+}
+
+// icyPCMPosition maps an offset in the compressed stream to decoded PCM bytes
+// using the advertised bitrate (icy-br, kbit/s). It is exact for CBR streams.
+// Without a bitrate the position is unknown and -1 is returned.
+func icyPCMPosition(audioOffset int64, bitrateKbps int) int64 {
+	if bitrateKbps <= 0 {
+		return -1
+	}
+	return audioOffset * ringbuffer.BytesPerSecond / (int64(bitrateKbps) * 1000 / 8)
+}
+
+// parseICYBitrate reads an icy-br header, which some servers send as "128,128".
+func parseICYBitrate(v string) int {
+	v, _, _ = strings.Cut(v, ",")
+	br, _ := strconv.Atoi(strings.TrimSpace(v))
+	return br
+}
+
+func latin1ToUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	r := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		r[i] = rune(s[i])
+	}
+	return string(r)
+}
+
+// maxID3Scan bounds how much of an HLS segment is searched for ID3 tags.
+const maxID3Scan = 256 * 1024
+
+// segmentMetadata finds the title and artist in the ID3 tags of an HLS
+// segment: at the start of packed audio (AAC, MP3), or in timed metadata
+// packets of an MPEG-TS segment.
+func segmentMetadata(data []byte) (Metadata, bool) {
+	if bytes.HasPrefix(data, []byte("ID3")) {
+		return parseID3(data)
+	}
+	const tsPacket = 188
+	if len(data) < tsPacket || data[0] != 0x47 {
+		return Metadata{}, false
+	}
+	for off := 0; off+tsPacket <= len(data); off += tsPacket {
+		pkt := data[off : off+tsPacket]
+		if pkt[0] != 0x47 || pkt[1]&0x40 == 0 { // not a payload unit start
+			continue
+		}
+		payload := pkt[4:]
+		switch pkt[3] >> 4 & 3 {
+		case 2: // adaptation field only
+			continue
+		case 3:
+			if int(pkt[4])+1 > len(payload) {
+				continue
+			}
+			payload = payload[int(pkt[4])+1:]
+		}
+		// PES header of a private stream 1 packet (timed ID3 metadata).
+		if len(payload) < 9 || !bytes.HasPrefix(payload, []byte{0, 0, 1, 0xBD}) {
+			continue
+		}
+		if start := 9 + int(payload[8]); start < len(payload) {
+			if m, ok := parseID3(payload[start:]); ok {
+				return m, true
+			}
+		}
+	}
+	return Metadata{}, false
+}
+
+// parseID3 reads the TIT2 (title) and TPE1 (artist) frames of an ID3v2.3 or
+// v2.4 tag. A tag cut short is read as far as it goes.
+func parseID3(data []byte) (Metadata, bool) {
+	if len(data) < 10 || !bytes.HasPrefix(data, []byte("ID3")) {
+		return Metadata{}, false
+	}
+	version, flags := data[3], data[5]
+	if version != 3 && version != 4 {
+		return Metadata{}, false
+	}
+	end := min(len(data), 10+syncsafe(data[6:10]))
+	pos := 10
+	if flags&0x40 != 0 && pos+4 <= end { // extended header
+		if version == 4 {
+			pos += syncsafe(data[pos : pos+4])
+		} else {
+			pos += 4 + int(binary.BigEndian.Uint32(data[pos:pos+4]))
+		}
+	}
+
+	var m Metadata
+	for pos+10 <= end {
+		id := string(data[pos : pos+4])
+		if id[0] == 0 {
+			break // padding
+		}
+		size := int(binary.BigEndian.Uint32(data[pos+4 : pos+8]))
+		if version == 4 {
+			size = syncsafe(data[pos+4 : pos+8])
+		}
+		pos += 10
+		if size < 0 || pos+size > end {
+			break
+		}
+		switch id {
+		case "TIT2":
+			m.Title = id3Text(data[pos : pos+size])
+		case "TPE1":
+			m.Artist = id3Text(data[pos : pos+size])
+		}
+		pos += size
+	}
+	return m, m.Title != "" || m.Artist != ""
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// id3Text decodes a text frame body: an encoding byte, then the text.
+func id3Text(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	enc, b := b[0], b[1:]
+	var s string
+	switch enc {
+	case 0: // ISO-8859-1
+		s = latin1ToUTF8(string(b))
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		order := binary.ByteOrder(binary.BigEndian)
+		if enc == 1 && len(b) >= 2 {
+			switch {
+			case b[0] == 0xff && b[1] == 0xfe:
+				order, b = binary.LittleEndian, b[2:]
+			case b[0] == 0xfe && b[1] == 0xff:
+				b = b[2:]
+			}
+		}
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = order.Uint16(b[2*i:])
+		}
+		s = string(utf16.Decode(u))
+	default: // UTF-8
+		s = string(b)
+	}
+	// v2.4 separates multiple values with NUL; keep the first.
+	s, _, _ = strings.Cut(s, "\x00")
+	return strings.TrimSpace(s)
+}
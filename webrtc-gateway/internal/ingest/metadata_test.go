@@ -0,0 +1,131 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// icyBlock encodes one ICY metadata block.
+func icyBlock(meta string) []byte {
+	n := (len(meta) + 15) / 16
+	b := make([]byte, 1+n*16)
+	b[0] = byte(n)
+	copy(b[1:], meta)
+	return b
+}
+
+func TestICYReaderStripsMetadata(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bytes.Repeat([]byte("a"), 8))
+	stream.Write(icyBlock("StreamTitle='Nina Simone - Sinnerman';StreamUrl='';"))
+	stream.Write(bytes.Repeat([]byte("b"), 8))
+	stream.Write([]byte{0}) // unchanged
+	stream.Write(bytes.Repeat([]byte("c"), 8))
+	stream.Write(icyBlock("StreamTitle='Rock 'n' Roll News';"))
+	stream.Write(bytes.Repeat([]byte("d"), 3))
+
+	type seen struct {
+		title  string
+		offset int64
+	}
+	var titles []seen
+	r := newICYReader(&stream, 8, func(title string, off int64) {
+		titles = append(titles, seen{title, off})
+	})
+	audio, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(audio) != "aaaaaaaabbbbbbbbccccccccddd" {
+		t.Errorf("audio = %q", audio)
+	}
+	want := []seen{{"Nina Simone - Sinnerman", 8}, {"Rock 'n' Roll News", 24}}
+	if len(titles) != len(want) || titles[0] != want[0] || titles[1] != want[1] {
+		t.Errorf("titles = %+v, want %+v", titles, want)
+	}
+
+	m := icyMetadata(titles[0].title)
+	if m.Artist != "Nina Simone" || m.Title != "Sinnerman" {
+		t.Errorf("split = %+v", m)
+	}
+}
+
+func TestICYPCMPosition(t *testing.T) {
+	// One second of a 128 kbit/s stream is one second of PCM.
+	if got := icyPCMPosition(16000, 128); got != ringbuffer.BytesPerSecond {
+		t.Errorf("position = %d, want %d", got, ringbuffer.BytesPerSecond)
+	}
+	if got := icyPCMPosition(16000, parseICYBitrate("")); got != -1 {
+		t.Errorf("position without bitrate = %d", got)
+	}
+}
+
+// id3v23 builds an ID3v2.3 tag with Latin-1 text frames.
+func id3v23(frames map[string]string) []byte {
+	var body bytes.Buffer
+	for id, text := range frames {
+		body.WriteString(id)
+		binary.Write(&body, binary.BigEndian, uint32(len(text)+1))
+		body.Write([]byte{0, 0, 0})
+		body.WriteString(text)
+	}
+	size := body.Len()
+	hdr := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(hdr, body.Bytes()...)
+}
+
+func TestSegmentMetadata(t *testing.T) {
+	tag := id3v23(map[string]string{"TIT2": "Morning Show", "TPE1": "Radio Uno", "PRIV": "x"})
+
+	// Packed audio: the tag leads the segment.
+	m, ok := segmentMetadata(append(tag, 0xff, 0xf1, 0x50))
+	if !ok || m.Title != "Morning Show" || m.Artist != "Radio Uno" {
+		t.Errorf("packed audio: %+v, %v", m, ok)
+	}
+
+	// MPEG-TS: the tag is the payload of a private stream 1 PES packet.
+	pkt := make([]byte, 188)
+	copy(pkt, []byte{0x47, 0x40 | 0x01, 0x00, 0x10}) // payload unit start, payload only
+	pes := append([]byte{0, 0, 1, 0xBD, 0, 0, 0x80, 0x80, 5, 0, 0, 0, 0, 0}, tag...)
+	copy(pkt[4:], pes)
+	audioPkt := make([]byte, 188)
+	audioPkt[0] = 0x47
+	m, ok = segmentMetadata(append(audioPkt, pkt...))
+	if !ok || m.Title != "Morning Show" {
+		t.Errorf("mpeg-ts: %+v, %v", m, ok)
+	}
+
+	if _, ok := segmentMetadata([]byte("no tags here")); ok {
+		t.Error("found metadata in a segment without tags")
+	}
+}
+
+func TestMetadataQueueRelease(t *testing.T) {
+	rb := ringbuffer.New(5)
+	var got []Metadata
+	q := newMetadataQueue(func(m Metadata) { got = append(got, m) })
+
+	start := time.Now()
+	rb.WriteAt(make([]byte, 1000), start)
+	q.push(Metadata{Title: "A"}, 1200)
+	q.push(Metadata{Title: "A"}, 1400) // repeat, dropped
+	q.release(1000, rb)
+	if len(got) != 0 {
+		t.Fatalf("released before its audio was read: %+v", got)
+	}
+
+	rb.WriteAt(make([]byte, 1000), start.Add(ringbuffer.BytesDuration(1000)))
+	q.release(2000, rb)
+	if len(got) != 1 || got[0].Offset != 1200 {
+		t.Fatalf("released %+v, want A at offset 1200", got)
+	}
+	if q.Current() == nil || q.Current().Title != "A" {
+		t.Errorf("current = %+v", q.Current())
+	}
+}
@@ -0,0 +1,266 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxErrorHistory bounds Status.ErrorHistory.
+	maxErrorHistory = 10
+	// reconnectStableAfter is how long a connection must last for the attempt
+	// count and backoff to start over after it drops.
+	reconnectStableAfter = 30 * time.Second
+)
+
+// ReconnectPolicy decides whether and when a failed source is restarted.
+type ReconnectPolicy struct {
+	// MaxAttempts is how many times in a row a source is restarted before the
+	// ingest fails (0 = never).
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt; it doubles with
+	// every further attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes each delay by up to ±Jitter of itself (0–1).
+	Jitter float64
+}
+
+// Validate reports whether the policy is usable.
+func (p ReconnectPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0 || p.MaxAttempts > 100:
+		return fmt.Errorf("maxAttempts must be between 0 and 100")
+	case p.InitialBackoff < 100*time.Millisecond || p.InitialBackoff > 5*time.Minute:
+		return fmt.Errorf("initialBackoffMs must be between 100 and 300000")
+	case p.MaxBackoff < p.InitialBackoff || p.MaxBackoff > 30*time.Minute:
+		return fmt.Errorf("maxBackoffMs must be between initialBackoffMs and 1800000")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff returns the delay before the given attempt (1-based), before jitter.
+func (p ReconnectPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	return time.Duration(min(d, float64(p.MaxBackoff)))
+}
+
+func (p ReconnectPolicy) delay(attempt int) time.Duration {
+	d := float64(p.Backoff(attempt))
+	d += d * p.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(d)
+}
+
+// ReconnectAttempt describes a restart about to happen.
+type ReconnectAttempt struct {
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+	Err         error
+}
+
+// ErrorRecord is one entry in Status.ErrorHistory.
+type ErrorRecord struct {
+	Time    time.Time `json:"time"`
+	Error   string    `json:"error"`
+	Attempt int       `json:"attempt,omitempty"` // reconnect attempt it triggered, if any
+}
+
+// permanentError marks a failure that restarting the source cannot fix, such
+// as a rejected URL or a 404.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err is a failure reconnecting cannot fix.
+func IsPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+// ReconnectingSource restarts a source that fails, per a ReconnectPolicy. A
+// source that ends normally or is stopped is not restarted.
+type ReconnectingSource struct {
+	src         Source
+	policy      ReconnectPolicy
+	maxDur      time.Duration
+	onReconnect func(ReconnectAttempt)
+	logger      *zap.Logger
+
+	mu           sync.Mutex
+	cancel       context.CancelFunc
+	reconnecting bool
+	reconnects   int
+	carried      int64 // bytes read by earlier connections
+	history      []ErrorRecord
+}
+
+// NewReconnectingSource wraps src. onReconnect, if set, is called before each
+// restart. maxDurationSec bounds the whole ingest, across restarts.
+func NewReconnectingSource(src Source, policy ReconnectPolicy, maxDurationSec int,
+	onReconnect func(ReconnectAttempt), logger *zap.Logger) *ReconnectingSource {
+
+	return &ReconnectingSource{
+		src:         src,
+		policy:      policy,
+		maxDur:      time.Duration(maxDurationSec) * time.Second,
+		onReconnect: onReconnect,
+		logger:      logger,
+	}
+}
+
+// Unwrap returns the wrapped source.
+func (r *ReconnectingSource) Unwrap() Source {
+	return r.src
+}
+
+// Pause pauses the wrapped source, if it is Seekable.
+func (r *ReconnectingSource) Pause() error {
+	if s, ok := r.src.(Seekable); ok {
+		return s.Pause()
+	}
+	return ErrNotSeekable
+}
+
+// Resume resumes the wrapped source, if it is Seekable.
+func (r *ReconnectingSource) Resume() error {
+	if s, ok := r.src.(Seekable); ok {
+		return s.Resume()
+	}
+	return ErrNotSeekable
+}
+
+// Seek seeks the wrapped source, if it is Seekable.
+func (r *ReconnectingSource) Seek(pos time.Duration) error {
+	if s, ok := r.src.(Seekable); ok {
+		return s.Seek(pos)
+	}
+	return ErrNotSeekable
+}
+
+// Start runs the source, restarting it after failures. Blocks until the source
+// ends, is stopped, or fails more than MaxAttempts times in a row.
+func (r *ReconnectingSource) Start(ctx context.Context) error {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return fmt.Errorf("ingest already running")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	if r.maxDur > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, r.maxDur)
+	}
+	r.cancel = cancel
+	r.mu.Unlock()
+
+	defer func() {
+		cancel()
+		r.mu.Lock()
+		r.cancel = nil
+		r.reconnecting = false
+		r.mu.Unlock()
+	}()
+
+	attempt := 0
+	for {
+		connectedAt := time.Now()
+		err := r.src.Start(runCtx)
+		if err == nil || runCtx.Err() != nil {
+			return nil
+		}
+		if time.Since(connectedAt) >= reconnectStableAfter {
+			attempt = 0
+		}
+		attempt++
+
+		retry := attempt <= r.policy.MaxAttempts && !IsPermanent(err)
+		rec := ErrorRecord{Time: time.Now(), Error: err.Error()}
+		if retry {
+			rec.Attempt = attempt
+		}
+		r.mu.Lock()
+		r.history = append(r.history, rec)
+		if len(r.history) > maxErrorHistory {
+			r.history = r.history[len(r.history)-maxErrorHistory:]
+		}
+		r.reconnecting = retry
+		if retry {
+			r.carried += r.src.Status().BytesRead
+			r.reconnects++
+		}
+		r.mu.Unlock()
+		if !retry {
+			return err
+		}
+
+		delay := r.policy.delay(attempt)
+		r.logger.Warn("ingest dropped, reconnecting",
+			zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		if r.onReconnect != nil {
+			r.onReconnect(ReconnectAttempt{
+				Attempt:     attempt,
+				MaxAttempts: r.policy.MaxAttempts,
+				Delay:       delay,
+				Err:         err,
+			})
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-runCtx.Done():
+			t.Stop()
+			return nil
+		}
+	}
+}
+
+// Stop terminates the ingest, including any pending reconnect. Idempotent.
+func (r *ReconnectingSource) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	r.src.Stop()
+}
+
+// Status returns the wrapped source's status, in state "reconnecting" while
+// waiting to restart it, with byte counts and errors accumulated across
+// connections.
+func (r *ReconnectingSource) Status() Status {
+	st := r.src.Status()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Until the restarted source is running it still reports the failed
+	// connection's state, or is just starting.
+	if r.reconnecting && st.State != StateRunning && st.State != StatePaused {
+		if st.State == StateError {
+			st.BytesRead = 0 // already in carried
+		}
+		st.State = StateReconnecting
+	}
+	st.BytesRead += r.carried
+	st.Reconnects = r.reconnects
+	st.ErrorHistory = append([]ErrorRecord(nil), r.history...)
+	return st
+}
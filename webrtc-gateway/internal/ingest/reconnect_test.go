@@ -0,0 +1,193 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// flakySource fails with each of errs in turn, then ends normally.
+type flakySource struct {
+	mu    sync.Mutex
+	errs  []error
+	runs  int
+	state string
+}
+
+func (f *flakySource) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs++
+	if len(f.errs) == 0 {
+		f.state = StateStopped
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	f.state = StateError
+	return err
+}
+
+func (f *flakySource) Stop() {}
+
+func (f *flakySource) Status() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Status{State: f.state, BytesRead: 100}
+}
+
+func testPolicy(attempts int) ReconnectPolicy {
+	return ReconnectPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+}
+
+func TestReconnectingSourceRecovers(t *testing.T) {
+	src := &flakySource{errs: []error{errors.New("reset"), errors.New("reset")}}
+	var attempts []int
+	r := NewReconnectingSource(src, testPolicy(3), 0, func(a ReconnectAttempt) {
+		attempts = append(attempts, a.Attempt)
+	}, zap.NewNop())
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if src.runs != 3 || len(attempts) != 2 || attempts[1] != 2 {
+		t.Errorf("runs = %d, attempts = %v", src.runs, attempts)
+	}
+	st := r.Status()
+	if st.Reconnects != 2 || len(st.ErrorHistory) != 2 || st.BytesRead != 300 {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestReconnectingSourceGivesUp(t *testing.T) {
+	src := &flakySource{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	r := NewReconnectingSource(src, testPolicy(2), 0, nil, zap.NewNop())
+	if err := r.Start(context.Background()); err == nil || err.Error() != "c" {
+		t.Errorf("Start = %v, want the last error", err)
+	}
+	st := r.Status()
+	if st.State != StateError || len(st.ErrorHistory) != 3 || st.ErrorHistory[2].Attempt != 0 {
+		t.Errorf("status = %+v", st)
+	}
+
+	// Permanent failures are not retried.
+	src = &flakySource{errs: []error{permanent(errors.New("404"))}}
+	r = NewReconnectingSource(src, testPolicy(5), 0, nil, zap.NewNop())
+	if err := r.Start(context.Background()); !IsPermanent(err) || src.runs != 1 {
+		t.Errorf("Start = %v after %d runs", err, src.runs)
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	p := ReconnectPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := p.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.delay(2); d < time.Second || d > 3*time.Second {
+			t.Fatalf("jittered delay %v outside ±50%% of 2s", d)
+		}
+	}
+}
+
+// fakeFFmpeg makes decode run a stand-in that passes its input through as PCM.
+// It returns the file the stand-in appends its arguments to, a line per run.
+func fakeFFmpeg(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\necho \"$@\" >> \"$0.args\"\nexec cat\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := ffmpegPath
+	ffmpegPath = path
+	t.Cleanup(func() { ffmpegPath = old })
+	return path + ".args"
+}
+
+// testURLSource returns a URL source fetching srv with its own client, as the
+// default one refuses loopback addresses.
+func testURLSource(srv *httptest.Server, rb *ringbuffer.RingBuffer) *FFmpegURLSource {
+	f := newFFmpegSource(TypeURL, srv.URL, nil, false, rb, 0, zap.NewNop())
+	f.open = func(ctx context.Context, offset int64) (io.ReadCloser, int64, error) {
+		return f.openHTTP(ctx, srv.Client(), offset)
+	}
+	return f
+}
+
+func TestReconnectLiveStreamClosed(t *testing.T) {
+	fakeFFmpeg(t)
+	audio := make([]byte, 10*chunkSize)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// An Icecast stream, which the server then ends cleanly.
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("Icy-Name", "test radio")
+		w.Write(audio)
+		w.(http.Flusher).Flush()
+	}))
+	defer srv.Close()
+
+	rb := ringbuffer.New(5)
+	src := testURLSource(srv, rb)
+	r := NewReconnectingSource(src, testPolicy(2), 0, nil, zap.NewNop())
+	err := r.Start(context.Background())
+	if !errors.Is(err, errLiveStreamEnded) {
+		t.Errorf("Start = %v, want %v after the last attempt", err, errLiveStreamEnded)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("%d requests, want 3 (reconnected twice)", n)
+	}
+	st := r.Status()
+	if st.Reconnects != 2 || st.BytesRead != int64(3*len(audio)) || rb.Written() != 3*len(audio) {
+		t.Errorf("status = %+v, buffered %d", st, rb.Written())
+	}
+}
+
+func TestFileEndsWithoutReconnect(t *testing.T) {
+	for name, chunked := range map[string]bool{"with length": false, "chunked": true} {
+		t.Run(name, func(t *testing.T) {
+			fakeFFmpeg(t)
+			audio := make([]byte, 10*chunkSize)
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Content-Type", "audio/mpeg")
+				if !chunked {
+					w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+				}
+				w.Write(audio)
+				if chunked {
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer srv.Close()
+
+			rb := ringbuffer.New(5)
+			r := NewReconnectingSource(testURLSource(srv, rb), testPolicy(2), 0, nil, zap.NewNop())
+			if err := r.Start(context.Background()); err != nil {
+				t.Errorf("Start = %v", err)
+			}
+			if n := requests.Load(); n != 1 || rb.Written() != len(audio) {
+				t.Errorf("%d requests, buffered %d", n, rb.Written())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+// Types of the built-in sources.
+const (
+	TypeURL  = "url"  // http(s) media, decoded by ffmpeg
+	TypeHLS  = "hls"  // HLS playlist
+	TypeFile = "file" // file in the server-side media library
+	TypeS3   = "s3"   // object fetched from an S3-compatible endpoint
+	TypeTone = "tone" // generated test tone
+)
+
+// Request describes the source an ingest start asks for.
+type Request struct {
+	// Type names a registered source. When empty it is picked from the URL scheme.
+	Type string
+	URL  string
+	HLS  HLSOptions
+}
+
+// Env is what a source writes to and is limited by.
+type Env struct {
+	RingBuffer     *ringbuffer.RingBuffer
+	MaxDurationSec int
+	Relay          Relay // nil unless the audio is played live
+	Logger         *zap.Logger
+	// Reconnect restarts sources of types that allow it after they fail;
+	// OnReconnect, if set, is told about each restart.
+	Reconnect   ReconnectPolicy
+	OnReconnect func(ReconnectAttempt)
+	// OnMetadata, if set, is told when a stream's title or artist changes.
+	OnMetadata func(Metadata)
+}
+
+// Factory creates one type of source.
+type Factory struct {
+	// Schemes are the URL schemes that select this type when a request has none.
+	Schemes []string
+	// Match, when set, further restricts which URLs with those schemes are
+	// selected, e.g. by extension.
+	Match func(u *url.URL) bool
+	// Validate rejects requests the source cannot serve. It runs before any
+	// running ingest is stopped, so errors are reported without side effects.
+	Validate func(req Request) error
+	// New creates the source for a validated request.
+	New func(req Request, env Env) Source
+	// Reconnect marks sources worth restarting when they fail, i.e. network
+	// streams that drop.
+	Reconnect bool
+}
+
+// Registry maps source types to factories. It is not safe to register types
+// concurrently with lookups; populate it at startup.
+type Registry struct {
+	order     []string
+	factories map[string]Factory
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register adds or replaces the factory for typ. Scheme lookups try types in
+// the order they were first registered.
+func (r *Registry) Register(typ string, f Factory) {
+	if _, ok := r.factories[typ]; !ok {
+		r.order = append(r.order, typ)
+	}
+	r.factories[typ] = f
+}
+
+// Types returns the registered type names, sorted.
+func (r *Registry) Types() []string {
+	types := slices.Clone(r.order)
+	sort.Strings(types)
+	return types
+}
+
+// Resolve fills in req.Type from the URL scheme when it is empty and runs the
+// type's validation.
+func (r *Registry) Resolve(req Request) (Request, error) {
+	if len(req.URL) > maxURLLength {
+		return req, fmt.Errorf("URL too long (%d chars, max %d)", len(req.URL), maxURLLength)
+	}
+	if req.Type == "" {
+		typ, err := r.typeForURL(req.URL)
+		if err != nil {
+			return req, err
+		}
+		req.Type = typ
+	}
+	f, ok := r.factories[req.Type]
+	if !ok {
+		return req, fmt.Errorf("unknown source type %q", req.Type)
+	}
+	if f.Validate != nil {
+		if err := f.Validate(req); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+func (r *Registry) typeForURL(rawURL string) (string, error) {
+	if rawURL == "" {
+		return "", fmt.Errorf("url or type is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	for _, typ := range r.order {
+		f := r.factories[typ]
+		if slices.Contains(f.Schemes, u.Scheme) && (f.Match == nil || f.Match(u)) {
+			return typ, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+}
+
+// New creates the source for a request returned by Resolve, wrapped in a
+// ReconnectingSource when the type allows reconnecting and env.Reconnect does.
+func (r *Registry) New(req Request, env Env) (Source, error) {
+	f, ok := r.factories[req.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type %q", req.Type)
+	}
+	src := f.New(req, env)
+	if f.Reconnect && env.Reconnect.MaxAttempts > 0 {
+		src = NewReconnectingSource(src, env.Reconnect, env.MaxDurationSec, env.OnReconnect, env.Logger)
+	}
+	return src, nil
+}
+
+// SourceConfig configures the optional built-in sources.
+type SourceConfig struct {
+	// MediaLibraryDir enables the file source, rooted at this directory.
+	MediaLibraryDir string
+	// S3 enables the s3 source when its Endpoint is set.
+	S3 S3Config
+}
+
+// NewDefaultRegistry returns a registry with the built-in sources: http(s) URLs
+// (HLS for .m3u8), test tones, and — when configured — the media library and
+// S3 objects.
+func NewDefaultRegistry(cfg SourceConfig) *Registry {
+	r := NewRegistry()
+	r.Register(TypeHLS, Factory{
+		Schemes:  []string{"http", "https"},
+		Match:    func(u *url.URL) bool { return IsHLSURL(u.String()) },
+		Validate: func(req Request) error { return ValidateURL(req.URL) },
+		New: func(req Request, env Env) Source {
+			h := NewHLSSource(req.URL, req.HLS, env.RingBuffer, env.MaxDurationSec, env.Logger)
+			h.SetRelay(env.Relay)
+			if env.OnMetadata != nil {
+				h.SetMetadataHandler(env.OnMetadata)
+			}
+			return h
+		},
+		Reconnect: true,
+	})
+	r.Register(TypeURL, Factory{
+		Schemes:  []string{"http", "https"},
+		Validate: func(req Request) error { return ValidateURL(req.URL) },
+		New: func(req Request, env Env) Source {
+			f := NewFFmpegURLSource(req.URL, env.RingBuffer, env.MaxDurationSec, env.Logger)
+			f.SetRelay(env.Relay)
+			if env.OnMetadata != nil {
+				f.SetMetadataHandler(env.OnMetadata)
+			}
+			return f
+		},
+		Reconnect: true,
+	})
+	r.Register(TypeTone, toneFactory())
+	if cfg.MediaLibraryDir != "" {
+		r.Register(TypeFile, fileFactory(cfg.MediaLibraryDir))
+	}
+	if cfg.S3.Endpoint != "" {
+		r.Register(TypeS3, s3Factory(cfg.S3))
+	}
+	return r
+}
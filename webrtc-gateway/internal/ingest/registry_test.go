@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+func TestRegistryResolvesType(t *testing.T) {
+	r := NewDefaultRegistry(SourceConfig{MediaLibraryDir: t.TempDir()})
+	for _, tc := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{URL: "tone:?frequency=1000"}, TypeTone},
+		{Request{Type: TypeTone}, TypeTone},
+		{Request{URL: "s3://bucket/key.mp3"}, ""}, // s3 not configured
+		{Request{URL: "gopher://example.com/"}, ""},
+		{Request{Type: "bogus", URL: "tone:"}, ""},
+	} {
+		got, err := r.Resolve(tc.req)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("Resolve(%+v) accepted as %q, want an error", tc.req, got.Type)
+			}
+			continue
+		}
+		if err != nil || got.Type != tc.want {
+			t.Errorf("Resolve(%+v) = %q, %v; want %q", tc.req, got.Type, err, tc.want)
+		}
+	}
+
+	// Scheme lookups try HLS before plain URLs.
+	hls := r.factories[TypeHLS]
+	if u, _ := url.Parse("https://example.com/live/index.m3u8"); !hls.Match(u) {
+		t.Error("HLS factory should match .m3u8 URLs")
+	}
+	if typ, err := r.typeForURL("https://example.com/a.mp3"); err != nil || typ != TypeURL {
+		t.Errorf("typeForURL(.mp3) = %q, %v", typ, err)
+	}
+}
+
+func TestParseToneURL(t *testing.T) {
+	p, err := parseToneURL("tone:?frequency=1000&duration=2.5")
+	if err != nil || p.frequency != 1000 || p.durationSec != 2.5 {
+		t.Errorf("got %+v, %v", p, err)
+	}
+	for _, bad := range []string{"tone:?frequency=9000", "tone:?duration=0", "tone:?duration=x", "http://x/"} {
+		if _, err := parseToneURL(bad); err == nil {
+			t.Errorf("parseToneURL(%q) accepted", bad)
+		}
+	}
+}
+
+func TestLibraryPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "talks"), 0o755)
+	os.WriteFile(filepath.Join(root, "talks", "a.mp3"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(outside, "secret.mp3"), []byte("x"), 0o644)
+	if err := os.Symlink(filepath.Join(outside, "secret.mp3"), filepath.Join(root, "escape.mp3")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+
+	for _, ok := range []string{"talks/a.mp3", "file:talks/a.mp3", "file:///talks/a.mp3", "../talks/a.mp3"} {
+		if _, err := libraryPath(root, ok); err != nil {
+			t.Errorf("libraryPath(%q): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "talks", "escape.mp3", "file://otherhost/talks/a.mp3", "../../" + filepath.Base(outside) + "/secret.mp3"} {
+		if p, err := libraryPath(root, bad); err == nil {
+			t.Errorf("libraryPath(%q) = %s, want an error", bad, p)
+		}
+	}
+}
+
+func TestS3Fetch(t *testing.T) {
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.EscapedPath(), r.Header.Get("Authorization")
+		http.ServeContent(w, r, "a.mp3", time.Time{}, strings.NewReader("audio"))
+	}))
+	defer srv.Close()
+
+	if _, err := s3ObjectURL(srv.URL, "s3://Bad_Bucket/key"); err == nil {
+		t.Error("invalid bucket name accepted")
+	}
+
+	f := s3Factory(S3Config{Endpoint: srv.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	req := Request{Type: TypeS3, URL: "s3://media/talks/a b+c.mp3"}
+	if err := f.Validate(req); err != nil {
+		t.Fatal(err)
+	}
+	src := f.New(req, Env{RingBuffer: ringbuffer.New(5), Logger: zap.NewNop()}).(*FFmpegURLSource)
+	body, _, err := src.open(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if gotPath != "/media/talks/a%20b%2Bc.mp3" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		!strings.Contains(gotAuth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("authorization = %q", gotAuth)
+	}
+
+	// Opened part way, as after a seek.
+	body, start, err := src.open(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if start != 2 || string(got) != "dio" {
+		t.Errorf("open at 2 = %q from %d", got, start)
+	}
+	if st := src.Status(); st.Type != TypeS3 {
+		t.Errorf("status type = %q", st.Type)
+	}
+}
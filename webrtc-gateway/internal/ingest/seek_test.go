@@ -0,0 +1,264 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+func TestDecodeArgsFrom(t *testing.T) {
+	if args := decodeArgsFrom(0); !slices.Equal(args, decodeArgs) {
+		t.Errorf("args from 0 = %v", args)
+	}
+	args := decodeArgsFrom(90500 * time.Millisecond)
+	i := slices.Index(args, "-ss")
+	if i < 1 || args[i-1] != "pipe:0" || args[i+1] != "90.500" {
+		t.Errorf("args = %v, want -ss 90.500 after the input", args)
+	}
+}
+
+func TestReadPCMSeekTimeline(t *testing.T) {
+	rb := ringbuffer.New(5)
+	var bytesRead atomic.Int64
+	var origin time.Time
+	sink := pcmSink{rb: rb, bytesRead: &bytesRead, logger: zap.NewNop(), origin: &origin}
+
+	second := make([]byte, ringbuffer.BytesPerSecond)
+	if err := readPCM(context.Background(), bytes.NewReader(second), sink); err != nil {
+		t.Fatal(err)
+	}
+	// Seeked to 60s: the next audio is stamped a minute after the first.
+	sink.start = time.Minute
+	if err := readPCM(context.Background(), bytes.NewReader(second), sink); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rb.TimeAt(ringbuffer.BytesPerSecond).Sub(rb.TimeAt(0)); got != time.Minute {
+		t.Errorf("seeked audio is %v after the start, want 1m", got)
+	}
+	if bytesRead.Load() != 2*ringbuffer.BytesPerSecond {
+		t.Errorf("bytesRead = %d", bytesRead.Load())
+	}
+}
+
+func TestSeekableControl(t *testing.T) {
+	rb := ringbuffer.New(5)
+	live := NewFFmpegURLSource("https://radio.example.com/live", rb, 0, zap.NewNop())
+	if err := live.Pause(); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("Pause on a live stream = %v", err)
+	}
+
+	file := newFFmpegSource(TypeFile, "file:talk.mp3", nil, true, rb, 0, zap.NewNop())
+	if err := file.Seek(time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Seek before Start = %v", err)
+	}
+
+	// Paused (no decoder running), a seek just moves the position.
+	file.state, file.paused, file.resume = StatePaused, true, make(chan struct{})
+	if err := file.Seek(-time.Second); err == nil {
+		t.Error("negative seek accepted")
+	}
+	if err := file.Seek(90 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if st := file.Status(); !st.Seekable || st.PositionSec != 90 || st.State != StatePaused {
+		t.Errorf("status = %+v", st)
+	}
+	if err := file.Resume(); err != nil || file.paused {
+		t.Errorf("Resume = %v, paused = %v", err, file.paused)
+	}
+
+	// Wrapped for reconnects, control passes through.
+	var src Source = NewReconnectingSource(file, testPolicy(1), 0, nil, zap.NewNop())
+	if err := src.(Seekable).Seek(time.Second); err != nil {
+		t.Errorf("Seek through ReconnectingSource = %v", err)
+	}
+}
+
+// mp3Stream returns d of CBR 128 kbps 44.1kHz MPEG-1 Layer III frames (silent
+// payload) after a 1000-byte ID3v2 tag. Padding keeps the average at exactly
+// 16000 bytes/s, as an encoder would.
+func mp3Stream(d time.Duration) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 7, 0x5e}) // 990 bytes of tag
+	b.Write(make([]byte, 990))
+	frames := int(d.Seconds() * 44100 / 1152)
+	var spare int
+	for i := 0; i < frames; i++ {
+		hdr := []byte{0xff, 0xfb, 0x90, 0x00}
+		n := 417
+		if spare += 144 * 128000 % 44100; spare >= 44100 {
+			spare -= 44100
+			hdr[2] |= 0x02
+			n++
+		}
+		b.Write(hdr)
+		b.Write(make([]byte, n-4))
+	}
+	return b.Bytes()
+}
+
+// adtsStream returns n AAC ADTS frames of 371 bytes at 44.1kHz.
+func adtsStream(n int) []byte {
+	var b bytes.Buffer
+	for i := 0; i < n; i++ {
+		const size = 371
+		b.Write([]byte{0xff, 0xf1, 0x50, 0x80 | size>>11, size >> 3 & 0xff, size&0x07<<5 | 0x1f, 0xfc})
+		b.Write(make([]byte, size-7))
+	}
+	return b.Bytes()
+}
+
+func TestSniffByteRate(t *testing.T) {
+	r, ok := sniffByteRate(mp3Stream(10 * time.Second)[:1000+sniffBytes])
+	if !ok || r.dataStart != 1000 || math.Abs(r.perSecond-16000) > 80 {
+		t.Errorf("mp3: rate = %+v, ok = %v", r, ok)
+	}
+	if got := r.offset(time.Minute); math.Abs(float64(got-1000-60*16000)) > 60*80 {
+		t.Errorf("mp3: offset of 1m = %d", got)
+	}
+
+	r, ok = sniffByteRate(adtsStream(100))
+	if want := 371 * 44100.0 / 1024; !ok || r.dataStart != 0 || math.Abs(r.perSecond-want) > 1 {
+		t.Errorf("adts: rate = %+v, ok = %v, want %.0f bytes/s", r, ok, want)
+	}
+
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, sniffBytes)...)
+	for name, head := range map[string][]byte{
+		"wav":       wav,
+		"too short": mp3Stream(time.Second)[:1200],
+		"tag only":  mp3Stream(time.Second)[:500],
+	} {
+		if r, ok := sniffByteRate(head); ok {
+			t.Errorf("%s: rate = %+v", name, r)
+		}
+	}
+}
+
+// rangeServer serves content with range support and records the Range header
+// of each request.
+type rangeServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newRangeServer(content []byte) *rangeServer {
+	s := &rangeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		s.mu.Unlock()
+		http.ServeContent(w, r, "talk.mp3", time.Time{}, bytes.NewReader(content))
+	}))
+	return s
+}
+
+func (s *rangeServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ranges)
+}
+
+// waitFor polls cond until it holds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSeekLargeFile(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		content   []byte
+		wantRange bool
+	}{
+		// An hour of MP3 is opened at the seek position by a range request.
+		{"mp3", mp3Stream(time.Hour), true},
+		// Formats whose byte rate isn't known are decoded from the start.
+		{"unknown", bytes.Repeat([]byte("not audio "), 1<<20), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := fakeFFmpeg(t)
+			srv := newRangeServer(tt.content)
+			defer srv.Close()
+
+			f := testURLSource(srv.Server, ringbuffer.New(5))
+			done := make(chan error, 1)
+			go func() { done <- f.Start(context.Background()) }()
+			defer func() {
+				f.Stop()
+				<-done
+			}()
+			waitFor(t, "the source to run", func() bool {
+				return f.Status().State == StateRunning && f.bytesRead.Load() > 2*sniffBytes
+			})
+
+			if err := f.Seek(30 * time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			waitFor(t, "the source to reopen", func() bool { return len(srv.requests()) == 2 })
+			waitFor(t, "the decoder to restart", func() bool {
+				b, _ := os.ReadFile(argsFile)
+				return strings.Count(string(b), "\n") == 2
+			})
+
+			args, _ := os.ReadFile(argsFile)
+			restart := strings.Split(strings.TrimSpace(string(args)), "\n")[1]
+			rng := srv.requests()[1]
+			if tt.wantRange {
+				// Within a frame of the exact offset; the padding of the
+				// sniffed frames only approximates the average.
+				want := 1000 + 30*60*16000
+				off, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+				if err != nil || off < want-418 || off > want+418 || strings.Contains(restart, "-ss") {
+					t.Errorf("reopened with Range %q, decoder args %q; want Range near bytes=%d- and no -ss", rng, restart, want)
+				}
+			} else if rng != "" || !strings.Contains(restart, "-ss 1800.000") {
+				t.Errorf("reopened with Range %q, decoder args %q; want the whole file and -ss", rng, restart)
+			}
+			if pos := f.Status().PositionSec; pos < 1800 || pos > 1810 {
+				t.Errorf("position = %.1fs after the seek", pos)
+			}
+		})
+	}
+}
+
+func TestLibraryFileOpensAtOffset(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.mp3"), []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src := fileFactory(root).New(Request{Type: TypeFile, URL: "a.mp3"},
+		Env{RingBuffer: ringbuffer.New(1), Logger: zap.NewNop()}).(*FFmpegURLSource)
+	body, start, err := src.open(context.Background(), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if got, _ := io.ReadAll(body); start != 6 || string(got) != "6789" {
+		t.Errorf("open at 6 = %q from %d", got, start)
+	}
+}
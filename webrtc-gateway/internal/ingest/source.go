@@ -1,6 +1,10 @@
 package ingest
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // State constants for ingest source lifecycle.
 const (
@@ -8,6 +12,10 @@ const (
 	StateRunning  = "running"
 	StateStopped  = "stopped"
 	StateError    = "error"
+	// StateReconnecting: the source failed and is waiting to be restarted.
+	StateReconnecting = "reconnecting"
+	// StatePaused: a seekable source was paused and waits to be resumed.
+	StatePaused = "paused"
 )
 
 // Source is the interface for any audio ingest source (URL, file, etc.).
@@ -21,11 +29,50 @@ type Source interface {
 	Status() Status
 }
 
+// Seekable is implemented by sources that can pause and seek. Whether a
+// particular source supports it may only be known once it has started (a URL
+// turns out to be a file), so the methods return ErrNotSeekable when it does not.
+type Seekable interface {
+	// Pause stops ingesting until Resume. The ring buffer keeps what it has.
+	Pause() error
+	Resume() error
+	// Seek continues ingesting from pos into the source.
+	Seek(pos time.Duration) error
+}
+
+var (
+	// ErrNotSeekable is returned for pause and seek on a live stream.
+	ErrNotSeekable = errors.New("ingest source is not seekable")
+	// ErrNotRunning is returned for pause and seek when the ingest has ended.
+	ErrNotRunning = errors.New("ingest is not running")
+)
+
+// Relay receives ingested audio (PCM s16le 16kHz mono) to play it live. Write
+// blocks to pace the source at real time and returns early when ctx is done.
+// pcm is only valid for the duration of the call.
+type Relay interface {
+	Write(ctx context.Context, pcm []byte) error
+}
+
 // Status describes the current state of an ingest source.
 type Status struct {
+	Type            string  `json:"type,omitempty"`
 	State           string  `json:"state"`
 	SourceURL       string  `json:"sourceUrl"`
 	SecondsBuffered float64 `json:"secondsBuffered"`
 	BytesRead       int64   `json:"bytesRead"`
 	LastError       string  `json:"lastError,omitempty"`
+	Relaying        bool    `json:"relaying,omitempty"`
+	// HLS only: segments fetched, and how far playback trails the live edge.
+	SegmentsFetched int64   `json:"segmentsFetched,omitempty"`
+	LiveLatencySec  float64 `json:"liveLatencySec,omitempty"`
+	// Metadata is the stream's current title/artist, when it carries any.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Reconnects counts restarts after failures; ErrorHistory keeps the most
+	// recent failures, oldest first.
+	Reconnects   int           `json:"reconnects,omitempty"`
+	ErrorHistory []ErrorRecord `json:"errorHistory,omitempty"`
+	// Seekable sources (files) report the position reached in the media.
+	Seekable    bool    `json:"seekable,omitempty"`
+	PositionSec float64 `json:"positionSec,omitempty"`
 }
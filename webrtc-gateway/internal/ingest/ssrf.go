@@ -3,7 +3,10 @@ package ingest
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
+	"syscall"
+	"time"
 )
 
 const maxURLLength = 2048
@@ -13,6 +16,9 @@ const maxURLLength = 2048
 //   - scheme must be http or https
 //   - no embedded credentials (user:pass@host)
 //   - hostname must resolve to a public IP (no private/reserved ranges)
+//
+// This rejects bad URLs up front; the resolved IPs can change before the fetch,
+// so the NewHTTPClient dialer enforces the same ranges on every connection.
 func ValidateURL(rawURL string) error {
 	if len(rawURL) > maxURLLength {
 		return fmt.Errorf("URL too long (%d chars, max %d)", len(rawURL), maxURLLength)
@@ -52,18 +58,83 @@ func ValidateURL(rawURL string) error {
 	return nil
 }
 
+const (
+	maxRedirects = 5
+	dialTimeout  = 10 * time.Second
+)
+
+// NewHTTPClient returns the HTTP client ingest sources fetch through. Its
+// dialer checks the IP actually being connected to on every connection —
+// including after redirects and fresh DNS lookups — so a hostname that passed
+// ValidateURL cannot later rebind to, or redirect to, a private address.
+// The client has no overall timeout, as ingest responses may stream indefinitely.
+func NewHTTPClient() *http.Client {
+	return newHTTPClient(checkPublicIP)
+}
+
+func newHTTPClient(check func(net.IP) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("dial %s: not an IP address", address)
+			}
+			return check(ip)
+		},
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 nil, // a proxy would make the checked IP the proxy's
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.User != nil {
+				return fmt.Errorf("redirect to a URL with embedded credentials")
+			}
+			return nil
+		},
+	}
+}
+
+func checkPublicIP(ip net.IP) error {
+	if isPrivateIP(ip) {
+		return fmt.Errorf("connection to private/reserved IP %s blocked", ip)
+	}
+	return nil
+}
+
 var privateRanges []*net.IPNet
 
 func init() {
 	cidrs := []string{
+		"0.0.0.0/8",
 		"127.0.0.0/8",
 		"10.0.0.0/8",
+		"100.64.0.0/10", // carrier-grade NAT
 		"172.16.0.0/12",
+		"192.0.0.0/24",
 		"192.168.0.0/16",
 		"169.254.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4", // multicast
+		"240.0.0.0/4", // reserved, broadcast
+		"::/128",
 		"::1/128",
 		"fc00::/7",
 		"fe80::/10",
+		"ff00::/8", // multicast
 	}
 	for _, cidr := range cidrs {
 		_, network, _ := net.ParseCIDR(cidr)
@@ -72,6 +143,10 @@ func init() {
 }
 
 func isPrivateIP(ip net.IP) bool {
+	// IPv4-mapped IPv6 (::ffff:a.b.c.d) is checked as the IPv4 address.
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
 	for _, network := range privateRanges {
 		if network.Contains(ip) {
 			return true
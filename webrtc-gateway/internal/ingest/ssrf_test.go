@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":              false,
+		"2606:4700::1111":      false,
+		"127.0.0.1":            true,
+		"10.1.2.3":             true,
+		"169.254.169.254":      true,
+		"100.64.0.1":           true, // carrier-grade NAT
+		"100.127.255.254":      true,
+		"100.128.0.1":          false,
+		"0.0.0.0":              true,
+		"0.1.2.3":              true,
+		"224.0.0.251":          true, // multicast
+		"239.255.255.250":      true,
+		"255.255.255.255":      true,
+		"::":                   true,
+		"ff02::1":              true,
+		"::ffff:127.0.0.1":     true, // IPv4-mapped IPv6
+		"::ffff:169.254.1.1":   true,
+		"::ffff:100.64.0.1":    true,
+		"::ffff:93.184.216.34": false,
+	}
+	for addr, want := range cases {
+		if got := isPrivateIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPrivateIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestHTTPClientBlocksPrivateConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPClient().Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "private/reserved IP 127.0.0.1 blocked") {
+		t.Errorf("GET loopback returned %v, want blocked", err)
+	}
+}
+
+func TestHTTPClientChecksRedirectTargets(t *testing.T) {
+	// The server (on 127.0.0.1) is allowed; its redirect to 127.0.0.2 stands in
+	// for a redirect to an internal address and must be refused at connect time.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, port, _ := net.SplitHostPort(r.Host)
+		http.Redirect(w, r, "http://127.0.0.2:"+port+"/internal", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	client := newHTTPClient(func(ip net.IP) error {
+		if !ip.Equal(net.IPv4(127, 0, 0, 1)) {
+			return fmt.Errorf("connection to %s blocked", ip)
+		}
+		return nil
+	})
+	_, err := client.Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "connection to 127.0.0.2 blocked") {
+		t.Errorf("redirect returned %v, want blocked", err)
+	}
+}
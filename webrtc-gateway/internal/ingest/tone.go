@@ -0,0 +1,200 @@
+package ingest
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+const (
+	// The tone matches the session test tone (audio.GenerateSineWave), at the
+	// ring buffer's 16kHz. Kept here so ingest stays free of the cgo audio package.
+	toneSampleRate       = ringbuffer.BytesPerSecond / 2
+	defaultToneFrequency = 440.0
+	toneAmplitude        = 16000
+
+	defaultToneDurationSec = 10.0
+	maxToneDurationSec     = 3600.0
+	minToneFrequency       = 20.0
+	maxToneFrequency       = toneSampleRate / 2
+)
+
+// toneParams is what a tone: URL asks for, e.g. tone:?frequency=1000&duration=5.
+type toneParams struct {
+	frequency   float64
+	durationSec float64
+}
+
+func parseToneURL(rawURL string) (toneParams, error) {
+	p := toneParams{frequency: defaultToneFrequency, durationSec: defaultToneDurationSec}
+	if rawURL == "" {
+		return p, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return p, fmt.Errorf("invalid tone URL: %w", err)
+	}
+	if u.Scheme != "tone" {
+		return p, fmt.Errorf("tone sources need a tone: URL")
+	}
+	q := u.Query()
+	if v := q.Get("frequency"); v != "" {
+		if p.frequency, err = strconv.ParseFloat(v, 64); err != nil ||
+			p.frequency < minToneFrequency || p.frequency >= maxToneFrequency {
+			return p, fmt.Errorf("tone frequency must be in [%g, %d) Hz", minToneFrequency, maxToneFrequency)
+		}
+	}
+	if v := q.Get("duration"); v != "" {
+		if p.durationSec, err = strconv.ParseFloat(v, 64); err != nil ||
+			p.durationSec <= 0 || p.durationSec > maxToneDurationSec {
+			return p, fmt.Errorf("tone duration must be in (0, %g] seconds", maxToneDurationSec)
+		}
+	}
+	return p, nil
+}
+
+func toneFactory() Factory {
+	return Factory{
+		Schemes: []string{"tone"},
+		Validate: func(req Request) error {
+			_, err := parseToneURL(req.URL)
+			return err
+		},
+		New: func(req Request, env Env) Source {
+			p, _ := parseToneURL(req.URL)
+			return newToneSource(req.URL, p, env)
+		},
+	}
+}
+
+// ToneSource generates a sine tone into the ring buffer, exercising the ingest
+// pipeline without a network or ffmpeg.
+type ToneSource struct {
+	url    string
+	params toneParams
+	rb     *ringbuffer.RingBuffer
+	maxDur time.Duration
+	logger *zap.Logger
+	relay  Relay
+
+	mu        sync.Mutex
+	state     string
+	lastError string
+	cancel    context.CancelFunc
+
+	bytesRead atomic.Int64
+}
+
+func newToneSource(rawURL string, p toneParams, env Env) *ToneSource {
+	if rawURL == "" {
+		rawURL = "tone:"
+	}
+	return &ToneSource{
+		url:    rawURL,
+		params: p,
+		rb:     env.RingBuffer,
+		maxDur: time.Duration(env.MaxDurationSec) * time.Second,
+		logger: env.Logger.With(zap.String("ingestURL", rawURL)),
+		relay:  env.Relay,
+		state:  StateStopped,
+	}
+}
+
+// Start generates the tone. Blocks until it has all been written, ctx is
+// cancelled, or Stop is called.
+func (t *ToneSource) Start(ctx context.Context) error {
+	t.mu.Lock()
+	if t.state == StateRunning || t.state == StateStarting {
+		t.mu.Unlock()
+		return fmt.Errorf("ingest already running")
+	}
+	t.state = StateRunning
+	t.lastError = ""
+	t.bytesRead.Store(0)
+
+	ingestCtx, cancel := context.WithCancel(ctx)
+	if t.maxDur > 0 {
+		ingestCtx, cancel = context.WithTimeout(ctx, t.maxDur)
+	}
+	t.cancel = cancel
+	t.mu.Unlock()
+
+	defer cancel()
+
+	samples := int(t.params.durationSec * toneSampleRate)
+	r := &toneReader{step: 2 * math.Pi * t.params.frequency / toneSampleRate, left: samples}
+	err := readPCM(ingestCtx, r, pcmSink{
+		rb:        t.rb,
+		relay:     t.relay,
+		bytesRead: &t.bytesRead,
+		logger:    t.logger,
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.state = StateError
+		t.lastError = err.Error()
+		return fmt.Errorf("ingest failed: %w", err)
+	}
+	t.state = StateStopped
+	t.logger.Info("tone ingest completed", zap.Int64("bytesRead", t.bytesRead.Load()))
+	return nil
+}
+
+// Stop terminates the ingest. Idempotent.
+func (t *ToneSource) Stop() {
+	t.mu.Lock()
+	cancel := t.cancel
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Status returns a snapshot of current ingest state.
+func (t *ToneSource) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Status{
+		Type:            TypeTone,
+		State:           t.state,
+		SourceURL:       t.url,
+		SecondsBuffered: t.rb.Available(),
+		BytesRead:       t.bytesRead.Load(),
+		LastError:       t.lastError,
+		Relaying:        t.relay != nil,
+	}
+}
+
+// toneReader produces PCM s16le of a sine wave, left samples long.
+type toneReader struct {
+	phase, step float64
+	left        int
+}
+
+func (r *toneReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p)/2, r.left)
+	for i := 0; i < n; i++ {
+		v := int16(toneAmplitude * math.Sin(r.phase))
+		binary.LittleEndian.PutUint16(p[2*i:], uint16(v))
+		r.phase = math.Mod(r.phase+r.step, 2*math.Pi)
+	}
+	r.left -= n
+	return 2 * n, nil
+}
@@ -0,0 +1,190 @@
+// Package jitter reorders inbound RTP packets before they reach the decoder.
+package jitter
+
+import (
+	"sort"
+	"time"
+)
+
+// Packet is the part of an RTP packet the decoder needs.
+type Packet struct {
+	SequenceNumber uint16
+	Timestamp      uint32
+	Payload        []byte
+}
+
+// Outcome classifies a pushed packet.
+type Outcome int
+
+const (
+	// Accepted is a packet that arrived in order (or ahead of a gap).
+	Accepted Outcome = iota
+	// Reordered is a packet that filled a gap while later packets were held.
+	Reordered
+	// Late is a packet whose slot was already skipped; it is dropped.
+	Late
+	// Duplicate is a packet already held or released; it is dropped.
+	Duplicate
+)
+
+const (
+	// maxPackets bounds the buffer; a full buffer skips its gap immediately.
+	maxPackets = 50
+	// resetDistance is how far behind the playout point (in packets, ~60s at
+	// 20ms) a packet must be to be treated as a sender restart, not a late packet.
+	resetDistance = 3000
+	// decayPackets sets how slowly the delay relaxes toward the minimum:
+	// each in-order release removes 1/decayPackets of the excess.
+	decayPackets = 500
+)
+
+type entry struct {
+	ext     int64 // sequence number extended past 16-bit wraparound
+	pkt     Packet
+	arrival time.Time
+}
+
+// Buffer is a small adaptive jitter buffer. In-order packets pass straight
+// through; when a sequence number is missing, later packets are held for up to
+// the current delay waiting for it. The delay grows when packets arrive
+// reordered or late and decays back toward the minimum while the stream is clean.
+// Not thread-safe — use one per inbound track.
+type Buffer struct {
+	minDelay, maxDelay time.Duration
+	delay              time.Duration
+
+	started  bool
+	next     int64  // extended sequence number to release next
+	highest  int64  // highest extended sequence number seen
+	released uint64 // bit i set: next-1-i was released (not skipped)
+
+	held []entry // sorted by ext
+}
+
+// NewBuffer returns a jitter buffer whose hold time adapts within [minDelay, maxDelay].
+func NewBuffer(minDelay, maxDelay time.Duration) *Buffer {
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+	return &Buffer{minDelay: minDelay, maxDelay: maxDelay, delay: minDelay}
+}
+
+// Delay returns the current hold time for gaps.
+func (b *Buffer) Delay() time.Duration {
+	return b.delay
+}
+
+// Push adds a packet that arrived at now.
+func (b *Buffer) Push(pkt Packet, now time.Time) Outcome {
+	if !b.started {
+		b.started = true
+		b.next = int64(pkt.SequenceNumber)
+		b.highest = b.next
+	}
+	ext := b.extend(pkt.SequenceNumber)
+
+	if ext < b.next {
+		if b.next-ext > resetDistance {
+			b.reset(ext)
+		} else {
+			age := b.next - 1 - ext
+			if age < 64 && b.released>>uint(age)&1 == 1 {
+				return Duplicate
+			}
+			// Its slot was concealed already: hold gaps longer from now on.
+			b.delay = min(b.maxDelay, 2*b.delay)
+			return Late
+		}
+	}
+
+	i := sort.Search(len(b.held), func(i int) bool { return b.held[i].ext >= ext })
+	if i < len(b.held) && b.held[i].ext == ext {
+		return Duplicate
+	}
+
+	outcome := Accepted
+	if i < len(b.held) {
+		// Filled a gap: make sure the delay would have covered this wait.
+		outcome = Reordered
+		if wait := now.Sub(b.held[i].arrival); wait+wait/2 > b.delay {
+			b.delay = min(b.maxDelay, wait+wait/2)
+		}
+	}
+	b.held = append(b.held, entry{})
+	copy(b.held[i+1:], b.held[i:])
+	b.held[i] = entry{ext: ext, pkt: pkt, arrival: now}
+	if ext > b.highest {
+		b.highest = ext
+	}
+	return outcome
+}
+
+// Pop returns the next packet ready for decoding at now, if any. Packets come
+// out in sequence order; a gap is skipped once the packet after it has been
+// held for the current delay (the decoder then conceals the missing frames).
+func (b *Buffer) Pop(now time.Time) (Packet, bool) {
+	if len(b.held) == 0 {
+		return Packet{}, false
+	}
+	head := b.held[0]
+	if head.ext != b.next {
+		if now.Sub(head.arrival) < b.delay && len(b.held) < maxPackets {
+			return Packet{}, false
+		}
+		b.skipTo(head.ext)
+	} else {
+		b.delay -= (b.delay - b.minDelay) / decayPackets
+	}
+
+	b.held = b.held[1:]
+	b.next++
+	b.released = b.released<<1 | 1
+	return head.pkt, true
+}
+
+// Drain returns every held packet in sequence order, skipping gaps.
+func (b *Buffer) Drain() []Packet {
+	out := make([]Packet, 0, len(b.held))
+	for _, e := range b.held {
+		b.skipTo(e.ext)
+		b.next++
+		b.released = b.released<<1 | 1
+		out = append(out, e.pkt)
+	}
+	b.held = b.held[:0]
+	return out
+}
+
+// NextDeadline returns when Pop will next release a held packet without new
+// input, or the zero time if nothing is waiting on a gap.
+func (b *Buffer) NextDeadline() time.Time {
+	if len(b.held) == 0 || b.held[0].ext == b.next {
+		return time.Time{}
+	}
+	return b.held[0].arrival.Add(b.delay)
+}
+
+// extend maps a 16-bit sequence number to the extended sequence space, choosing
+// the value closest to the highest seen so far.
+func (b *Buffer) extend(seq uint16) int64 {
+	return b.highest + int64(int16(seq-uint16(b.highest)))
+}
+
+// skipTo advances the playout point over missing packets up to ext.
+func (b *Buffer) skipTo(ext int64) {
+	if n := ext - b.next; n > 0 {
+		if n >= 64 {
+			b.released = 0
+		} else {
+			b.released <<= uint(n)
+		}
+		b.next = ext
+	}
+}
+
+func (b *Buffer) reset(ext int64) {
+	b.held = b.held[:0]
+	b.next, b.highest = ext, ext
+	b.released = 0
+	b.delay = b.minDelay
+}
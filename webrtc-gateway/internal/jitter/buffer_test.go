@@ -0,0 +1,135 @@
+package jitter
+
+import (
+	"testing"
+	"time"
+)
+
+const frame = 20 * time.Millisecond
+
+func pkt(seq uint16) Packet {
+	return Packet{SequenceNumber: seq, Timestamp: uint32(seq) * 960}
+}
+
+// popAll releases everything ready at now.
+func popAll(b *Buffer, now time.Time) []uint16 {
+	var out []uint16
+	for {
+		p, ok := b.Pop(now)
+		if !ok {
+			return out
+		}
+		out = append(out, p.SequenceNumber)
+	}
+}
+
+func equal(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBufferReorders(t *testing.T) {
+	b := NewBuffer(40*time.Millisecond, 200*time.Millisecond)
+	t0 := time.Unix(0, 0)
+
+	var got []uint16
+	for i, seq := range []uint16{1, 3, 2, 4} {
+		now := t0.Add(time.Duration(i) * frame)
+		outcome := b.Push(pkt(seq), now)
+		if seq == 2 && outcome != Reordered {
+			t.Errorf("seq 2: outcome %v, want Reordered", outcome)
+		}
+		got = append(got, popAll(b, now)...)
+	}
+	if want := []uint16{1, 2, 3, 4}; !equal(got, want) {
+		t.Errorf("released %v, want %v", got, want)
+	}
+}
+
+func TestBufferWraparound(t *testing.T) {
+	b := NewBuffer(40*time.Millisecond, 200*time.Millisecond)
+	t0 := time.Unix(0, 0)
+
+	var got []uint16
+	for i, seq := range []uint16{65534, 0, 65535, 1} {
+		now := t0.Add(time.Duration(i) * frame)
+		b.Push(pkt(seq), now)
+		got = append(got, popAll(b, now)...)
+	}
+	if want := []uint16{65534, 65535, 0, 1}; !equal(got, want) {
+		t.Errorf("released %v, want %v", got, want)
+	}
+}
+
+func TestBufferSkipsGapAfterDelay(t *testing.T) {
+	b := NewBuffer(40*time.Millisecond, 200*time.Millisecond)
+	t0 := time.Unix(0, 0)
+
+	b.Push(pkt(10), t0)
+	popAll(b, t0)
+	b.Push(pkt(12), t0.Add(frame))
+	if got := popAll(b, t0.Add(frame)); len(got) != 0 {
+		t.Fatalf("released %v before the delay expired", got)
+	}
+	if d := b.NextDeadline(); !d.Equal(t0.Add(frame + 40*time.Millisecond)) {
+		t.Errorf("deadline %v, want %v", d, t0.Add(frame+40*time.Millisecond))
+	}
+	if got := popAll(b, b.NextDeadline()); !equal(got, []uint16{12}) {
+		t.Fatalf("released %v after the delay, want [12]", got)
+	}
+
+	// 11 now arrives too late to be played, and the delay grows.
+	if outcome := b.Push(pkt(11), t0.Add(5*frame)); outcome != Late {
+		t.Errorf("seq 11: outcome %v, want Late", outcome)
+	}
+	if b.Delay() <= 40*time.Millisecond {
+		t.Errorf("delay %v did not grow after a late packet", b.Delay())
+	}
+}
+
+func TestBufferDuplicates(t *testing.T) {
+	b := NewBuffer(40*time.Millisecond, 200*time.Millisecond)
+	t0 := time.Unix(0, 0)
+
+	b.Push(pkt(1), t0)
+	popAll(b, t0)
+	if outcome := b.Push(pkt(1), t0); outcome != Duplicate {
+		t.Errorf("released duplicate: outcome %v", outcome)
+	}
+	b.Push(pkt(3), t0)
+	if outcome := b.Push(pkt(3), t0); outcome != Duplicate {
+		t.Errorf("held duplicate: outcome %v", outcome)
+	}
+	if got := b.Drain(); !equal(seqs(got), []uint16{3}) {
+		t.Errorf("drained %v, want [3]", seqs(got))
+	}
+}
+
+func TestBufferResetsOnSequenceRestart(t *testing.T) {
+	b := NewBuffer(40*time.Millisecond, 200*time.Millisecond)
+	t0 := time.Unix(0, 0)
+
+	b.Push(pkt(20000), t0)
+	popAll(b, t0)
+	if outcome := b.Push(pkt(5), t0.Add(frame)); outcome != Accepted {
+		t.Errorf("restarted stream: outcome %v, want Accepted", outcome)
+	}
+	if got := popAll(b, t0.Add(frame)); !equal(got, []uint16{5}) {
+		t.Errorf("released %v, want [5]", got)
+	}
+}
+
+func seqs(pkts []Packet) []uint16 {
+	out := make([]uint16, len(pkts))
+	for i, p := range pkts {
+		out[i] = p.SequenceNumber
+	}
+	return out
+}
@@ -23,6 +23,10 @@ var (
 		Name: "whats_gateway_active_ingests",
 		Help: "Number of active URL ingest sources",
 	})
+	ActiveCaptions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "whats_gateway_active_captions",
+		Help: "Number of sessions with live captioning running",
+	})
 )
 
 // Counters
@@ -59,6 +63,14 @@ var (
 		Name: "whats_gateway_rtp_gaps_total",
 		Help: "Total RTP sequence number gaps detected",
 	})
+	RTPJitterPacketsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "whats_gateway_rtp_jitter_packets_total",
+		Help: "Inbound RTP packets the jitter buffer reordered or dropped, by kind (reordered, late, duplicate)",
+	}, []string{"kind"})
+	RTPLostFramesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "whats_gateway_rtp_lost_frames_total",
+		Help: "Inbound frames lost to RTP gaps, by how they were filled (fec or plc)",
+	}, []string{"recovery"})
 	IngestsStartedTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "whats_gateway_ingests_started_total",
 		Help: "Total URL ingests started",
@@ -67,6 +79,18 @@ var (
 		Name: "whats_gateway_ingests_failed_total",
 		Help: "Total URL ingests that ended with errors",
 	})
+	IngestReconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "whats_gateway_ingest_reconnects_total",
+		Help: "Total restarts of ingest sources after they failed",
+	})
+	CaptionWindowsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "whats_gateway_caption_windows_total",
+		Help: "Total live caption windows by outcome",
+	}, []string{"outcome"})
+	DVRDiskErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "whats_gateway_dvr_disk_errors_total",
+		Help: "Total sessions whose disk-backed ring buffer tier failed and was dropped",
+	})
 )
 
 // Histograms
@@ -0,0 +1,143 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DiskTier holds audio that has aged out of a RingBuffer's memory in fixed-size
+// segment files under a per-buffer directory. Segment i holds the absolute stream
+// offsets [i*segmentBytes, (i+1)*segmentBytes). Whole segments are deleted once
+// more than maxBytes is retained.
+//
+// A DiskTier is owned by one RingBuffer. The buffer queues spilled audio in
+// memory and a background goroutine writes it out, so disk I/O never happens
+// under the buffer's lock; the files are guarded by the tier's own lock instead.
+type DiskTier struct {
+	dir          string
+	segmentBytes int
+	maxBytes     int
+
+	mu    sync.Mutex
+	start int // absolute offset of the oldest byte on disk
+	end   int // absolute offset one past the newest byte on disk
+
+	cur    *os.File // segment currently being appended to
+	curIdx int
+
+	wake     chan struct{} // audio queued; closed when the tier is detached
+	done     chan struct{} // closed once the writer has removed the files
+	closeErr error         // from removing the files, valid after done
+}
+
+// NewDiskTier creates dir and returns a disk tier retaining at least
+// maxSeconds of audio in segments of segmentSeconds each.
+func NewDiskTier(dir string, maxSeconds, segmentSeconds int) (*DiskTier, error) {
+	if segmentSeconds <= 0 || maxSeconds <= 0 {
+		return nil, fmt.Errorf("ringbuffer: invalid disk tier durations %ds/%ds", maxSeconds, segmentSeconds)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("ringbuffer: create disk tier dir: %w", err)
+	}
+	return &DiskTier{
+		dir:          dir,
+		segmentBytes: segmentSeconds * BytesPerSecond,
+		maxBytes:     maxSeconds * BytesPerSecond,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+func (t *DiskTier) segmentPath(idx int) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%010d.pcm", idx))
+}
+
+// append writes data at the end of the tier, enforces retention and returns
+// the offset of the oldest byte still on disk.
+func (t *DiskTier) append(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for len(data) > 0 {
+		idx := t.end / t.segmentBytes
+		if t.cur == nil || t.curIdx != idx {
+			t.closeCurrent()
+			f, err := os.OpenFile(t.segmentPath(idx), os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				return t.start, fmt.Errorf("ringbuffer: open segment: %w", err)
+			}
+			t.cur, t.curIdx = f, idx
+		}
+
+		pos := t.end % t.segmentBytes
+		n := min(len(data), t.segmentBytes-pos)
+		if _, err := t.cur.WriteAt(data[:n], int64(pos)); err != nil {
+			return t.start, fmt.Errorf("ringbuffer: write segment: %w", err)
+		}
+		data = data[n:]
+		t.end += n
+	}
+
+	// Drop the oldest segment while the rest still covers maxBytes.
+	for {
+		idx := t.start / t.segmentBytes
+		next := (idx + 1) * t.segmentBytes
+		if t.end-next < t.maxBytes {
+			break
+		}
+		if err := os.Remove(t.segmentPath(idx)); err != nil && !os.IsNotExist(err) {
+			return t.start, fmt.Errorf("ringbuffer: remove segment: %w", err)
+		}
+		t.start = next
+	}
+	return t.start, nil
+}
+
+// read fills dst with the audio starting at the absolute offset, which the
+// caller has checked has been written to disk. It fails with ErrRangeOverwritten
+// if retention has since removed it.
+func (t *DiskTier) read(dst []byte, offset int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if offset < t.start {
+		return ErrRangeOverwritten
+	}
+	for len(dst) > 0 {
+		idx := offset / t.segmentBytes
+		pos := offset % t.segmentBytes
+		n := min(len(dst), t.segmentBytes-pos)
+
+		f, err := os.Open(t.segmentPath(idx))
+		if err != nil {
+			return fmt.Errorf("ringbuffer: open segment: %w", err)
+		}
+		_, err = f.ReadAt(dst[:n], int64(pos))
+		f.Close()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("ringbuffer: read segment: %w", err)
+		}
+		dst = dst[n:]
+		offset += n
+	}
+	return nil
+}
+
+// Must hold t.mu.
+func (t *DiskTier) closeCurrent() {
+	if t.cur != nil {
+		t.cur.Close()
+		t.cur = nil
+	}
+}
+
+// close releases the current segment and removes the tier's directory.
+func (t *DiskTier) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeCurrent()
+	return os.RemoveAll(t.dir)
+}
@@ -1,11 +1,63 @@
 package ringbuffer
 
-import "sync"
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
 
 // BytesPerSecond is the number of bytes per second for PCM s16le, 16kHz, mono audio.
 // 16000 samples/sec * 2 bytes/sample = 32000 bytes/sec.
 const BytesPerSecond = 16000 * 2
 
+var (
+	// ErrRangeOverwritten is returned when part of a requested range is older
+	// than the oldest audio still held in the buffer.
+	ErrRangeOverwritten = errors.New("ringbuffer: range has been overwritten")
+	// ErrRangeNotWritten is returned when a requested range extends past the
+	// current write position.
+	ErrRangeNotWritten = errors.New("ringbuffer: range has not been written yet")
+	// ErrInvalidRange is returned for a range with a negative start or end < start.
+	ErrInvalidRange = errors.New("ringbuffer: invalid range")
+)
+
+// activityFrameBytes is the granularity of speech tagging: 20ms of 16kHz s16le.
+const activityFrameBytes = 640
+
+// maxSpeechRegions bounds the number of tracked speech regions per buffer.
+const maxSpeechRegions = 256
+
+// ActivityDetector classifies a frame of PCM s16le 16kHz mono audio as speech.
+// Implementations may keep state across frames; calls are serialized by the buffer.
+type ActivityDetector interface {
+	IsSpeech(frame []byte) bool
+}
+
+// timelineTolerance is how far a write's capture time may deviate from the time
+// extrapolated from the previous timeline mark before a new mark is recorded.
+// It absorbs network jitter for writes timestamped on arrival.
+const timelineTolerance = 50 * time.Millisecond
+
+// maxTimeMarks bounds the timeline index per buffer.
+const maxTimeMarks = 1024
+
+// maxSpillBytes bounds the audio queued for a disk tier that can't keep up.
+const maxSpillBytes = 60 * BytesPerSecond
+
+// timeMark anchors an absolute stream offset to the wall-clock capture time of
+// its first sample. Audio between marks is assumed contiguous at BytesPerSecond.
+type timeMark struct {
+	offset int
+	at     time.Time
+}
+
+// Region is a span of the stream by absolute byte offset, [Start, End).
+type Region struct {
+	Start int
+	End   int
+}
+
 // RingBuffer holds a fixed-duration circular buffer of PCM s16le, 16kHz, mono audio.
 // It is safe for concurrent use from a single writer and single reader.
 type RingBuffer struct {
@@ -14,6 +66,18 @@ type RingBuffer struct {
 	writePos int
 	capacity int
 	written  int // total bytes ever written (for tracking fill level)
+
+	detector ActivityDetector
+	speech   []Region // speech regions still (at least partly) held, oldest first
+
+	timeline []timeMark // capture time discontinuities, oldest first
+
+	disk        *DiskTier // optional: audio spilled from memory, older than buf
+	diskStart   int       // oldest offset retained by the disk tier (incl. spill queue)
+	spillStart  int       // offset of the first queued byte not yet on disk
+	flushing    []byte    // queued audio being written by the disk writer
+	spill       []byte    // queued audio after flushing, waiting for the writer
+	onDiskError func(error)
 }
 
 // New creates a ring buffer that holds the specified number of seconds of audio.
@@ -25,17 +89,293 @@ func New(seconds int) *RingBuffer {
 	}
 }
 
+// SetActivityDetector enables speech tagging of all subsequent writes.
+func (rb *RingBuffer) SetActivityDetector(d ActivityDetector) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.detector = d
+}
+
+// SetDiskTier attaches a disk tier: from now on audio about to be overwritten
+// in memory is spilled to it, and reads can reach back as far as it retains.
+// Spilled audio is queued and written by a background goroutine; if the disk
+// fails or falls more than a minute behind, the tier is dropped,
+// falling back to memory only, and onError (if non-nil) is called with the
+// buffer lock held.
+func (rb *RingBuffer) SetDiskTier(t *DiskTier, onError func(error)) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	oldest := rb.memOldestLocked()
+	t.start, t.end = oldest, oldest
+	rb.disk = t
+	rb.diskStart, rb.spillStart = oldest, oldest
+	rb.onDiskError = onError
+	go rb.writeDisk(t)
+}
+
+// Close removes the disk tier's files, if any. The buffer stays usable in memory.
+func (rb *RingBuffer) Close() error {
+	rb.mu.Lock()
+	t := rb.disk
+	if t == nil {
+		rb.mu.Unlock()
+		return nil
+	}
+	rb.detachDiskLocked()
+	rb.mu.Unlock()
+
+	<-t.done
+	return t.closeErr
+}
+
+// detachDiskLocked discards the disk tier and its queue; its writer then
+// removes the files. Must hold rb.mu.
+func (rb *RingBuffer) detachDiskLocked() {
+	close(rb.disk.wake)
+	rb.disk = nil
+	rb.flushing, rb.spill = nil, nil
+}
+
+// dropDiskLocked discards the disk tier after an I/O error. Must hold rb.mu.
+func (rb *RingBuffer) dropDiskLocked(err error) {
+	rb.detachDiskLocked()
+	if rb.onDiskError != nil {
+		rb.onDiskError(err)
+	}
+}
+
+// writeDisk writes queued audio to t until t is detached, then removes its files.
+func (rb *RingBuffer) writeDisk(t *DiskTier) {
+	defer close(t.done)
+	defer func() { t.closeErr = t.close() }()
+
+	for range t.wake {
+		rb.mu.Lock()
+		if rb.disk != t {
+			rb.mu.Unlock()
+			return
+		}
+		rb.flushing, rb.spill = rb.spill, rb.flushing[:0]
+		data := rb.flushing
+		rb.mu.Unlock()
+
+		start, err := t.append(data)
+
+		rb.mu.Lock()
+		if rb.disk != t {
+			rb.mu.Unlock()
+			return
+		}
+		if err != nil {
+			rb.dropDiskLocked(err)
+			rb.mu.Unlock()
+			return
+		}
+		rb.spillStart += len(data)
+		rb.diskStart = start
+		rb.flushing = rb.flushing[:0]
+		rb.prune()
+		rb.mu.Unlock()
+	}
+}
+
 // Write appends PCM data to the buffer, overwriting the oldest data when full.
+// The data is assumed to have just been captured, i.e. its last sample is now.
 func (rb *RingBuffer) Write(data []byte) {
+	rb.WriteAt(data, time.Now().Add(-BytesDuration(len(data))))
+}
+
+// WriteAt is like Write but records at as the wall-clock capture time of the
+// first sample of data, for TimeAt. With an activity detector set, the written
+// audio is also tagged as speech/non-speech.
+func (rb *RingBuffer) WriteAt(data []byte, at time.Time) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
+	rb.markTime(at)
+
+	if rb.detector != nil {
+		rb.tagActivity(data)
+	}
+
 	for len(data) > 0 {
+		// Queue the bytes about to be overwritten for the disk, once the buffer has filled.
+		if rb.disk != nil && rb.written >= rb.capacity {
+			n := min(len(data), rb.capacity-rb.writePos)
+			rb.spill = append(rb.spill, rb.buf[rb.writePos:rb.writePos+n]...)
+		}
+
 		n := copy(rb.buf[rb.writePos:], data)
 		data = data[n:]
 		rb.writePos = (rb.writePos + n) % rb.capacity
 		rb.written += n
 	}
+
+	if rb.disk != nil && len(rb.spill) > 0 {
+		if len(rb.flushing)+len(rb.spill) > maxSpillBytes {
+			rb.dropDiskLocked(errors.New("ringbuffer: disk tier fell behind"))
+		} else {
+			select {
+			case rb.disk.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	rb.prune()
+}
+
+// markTime records a timeline mark for the next write unless its capture time
+// continues the previous mark. Must be called with rb.mu held, before rb.written advances.
+func (rb *RingBuffer) markTime(at time.Time) {
+	if n := len(rb.timeline); n > 0 {
+		last := rb.timeline[n-1]
+		expected := last.at.Add(BytesDuration(rb.written - last.offset))
+		if d := at.Sub(expected); d > -timelineTolerance && d < timelineTolerance {
+			return
+		}
+	}
+	rb.timeline = append(rb.timeline, timeMark{offset: rb.written, at: at})
+	if len(rb.timeline) > maxTimeMarks {
+		rb.timeline = rb.timeline[1:]
+	}
+}
+
+// tagActivity runs the detector over data in 20ms frames and extends or opens
+// speech regions. Must be called with rb.mu held, before rb.written advances.
+func (rb *RingBuffer) tagActivity(data []byte) {
+	base := rb.written
+	for off := 0; off < len(data); off += activityFrameBytes {
+		end := off + activityFrameBytes
+		if end > len(data) {
+			end = len(data)
+		}
+		if !rb.detector.IsSpeech(data[off:end]) {
+			continue
+		}
+		start, stop := base+off, base+end
+		if n := len(rb.speech); n > 0 && rb.speech[n-1].End == start {
+			rb.speech[n-1].End = stop
+			continue
+		}
+		rb.speech = append(rb.speech, Region{Start: start, End: stop})
+		if len(rb.speech) > maxSpeechRegions {
+			rb.speech = rb.speech[1:]
+		}
+	}
+}
+
+// prune drops speech regions and timeline marks whose audio has been
+// overwritten. The mark covering the oldest byte is kept. Must be called with rb.mu held.
+func (rb *RingBuffer) prune() {
+	oldest := rb.oldestLocked()
+	for len(rb.speech) > 0 && rb.speech[0].End <= oldest {
+		rb.speech = rb.speech[1:]
+	}
+	if len(rb.speech) > 0 && rb.speech[0].Start < oldest {
+		rb.speech[0].Start = oldest
+	}
+	for len(rb.timeline) > 1 && rb.timeline[1].offset <= oldest {
+		rb.timeline = rb.timeline[1:]
+	}
+}
+
+// TimeAt returns the wall-clock capture time of the byte at the absolute stream
+// offset, or the zero time if nothing has been written yet.
+func (rb *RingBuffer) TimeAt(offset int) time.Time {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return Timeline(rb.timeline).TimeAt(offset)
+}
+
+// Timeline returns the capture time index for the absolute range [start, end).
+// Unlike TimeAt on the buffer, it stays valid after that audio is overwritten.
+func (rb *RingBuffer) Timeline(start, end int) Timeline {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	var tl Timeline
+	for i, m := range rb.timeline {
+		if m.offset >= end {
+			break
+		}
+		// Keep the mark covering start and every mark inside the range.
+		if i+1 < len(rb.timeline) && rb.timeline[i+1].offset <= start {
+			continue
+		}
+		tl = append(tl, m)
+	}
+	return tl
+}
+
+// Timeline maps absolute stream offsets to wall-clock capture times.
+type Timeline []timeMark
+
+// TimeAt returns the capture time of the byte at the absolute stream offset,
+// or the zero time if the timeline is empty.
+func (tl Timeline) TimeAt(offset int) time.Time {
+	if len(tl) == 0 {
+		return time.Time{}
+	}
+	// Last mark at or before offset; offsets before the first mark extrapolate from it.
+	i := sort.Search(len(tl), func(i int) bool { return tl[i].offset > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+	m := tl[i]
+	return m.at.Add(BytesDuration(offset - m.offset))
+}
+
+// BytesDuration returns the playback duration of n bytes of PCM s16le 16kHz mono.
+func BytesDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / BytesPerSecond
+}
+
+// Utterances returns up to n of the most recent speech regions still held in
+// the buffer, oldest first. The last region may still be growing.
+func (rb *RingBuffer) Utterances(n int) []Region {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if n > len(rb.speech) {
+		n = len(rb.speech)
+	}
+	out := make([]Region, n)
+	copy(out, rb.speech[len(rb.speech)-n:])
+	return out
+}
+
+// SpeechBounds narrows [start, end) to the span from the first detected speech
+// to the last, widened by pad bytes on each side and clamped to [start, end).
+// ok is false when no speech was detected in range (or no detector is set),
+// in which case the caller should keep the original range.
+func (rb *RingBuffer) SpeechBounds(start, end, pad int) (int, int, bool) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	first, last := -1, -1
+	for _, r := range rb.speech {
+		if r.End <= start || r.Start >= end {
+			continue
+		}
+		if first < 0 {
+			first = r.Start
+		}
+		last = r.End
+	}
+	if first < 0 {
+		return start, end, false
+	}
+
+	first -= pad
+	last += pad
+	if first < start {
+		first = start
+	}
+	if last > end {
+		last = end
+	}
+	return first, last, true
 }
 
 // Snapshot returns a copy of the last N seconds of audio.
@@ -113,6 +453,148 @@ func (rb *RingBuffer) SnapshotInto(seconds int, dst []byte) []byte {
 	return out
 }
 
+// OffsetForMs converts a position on the stream timeline, in milliseconds since
+// the first write, to an absolute byte offset.
+func OffsetForMs(ms int) int {
+	return ms * (BytesPerSecond / 1000)
+}
+
+// SnapshotRange copies the audio between the absolute stream offsets [start, end)
+// into dst, avoiding allocation. dst must have capacity >= end-start.
+// Unlike ReadSince the range is exact: it fails with ErrRangeOverwritten if start
+// is older than the oldest byte held, and ErrRangeNotWritten if end is in the future.
+func (rb *RingBuffer) SnapshotRange(start, end int, dst []byte) ([]byte, error) {
+	rb.mu.Lock()
+	if start < 0 || end < start {
+		rb.mu.Unlock()
+		return nil, ErrInvalidRange
+	}
+	if start < rb.oldestLocked() {
+		rb.mu.Unlock()
+		return nil, ErrRangeOverwritten
+	}
+	if end > rb.written {
+		rb.mu.Unlock()
+		return nil, ErrRangeNotWritten
+	}
+
+	out := dst[:end-start]
+	disk, n := rb.readLocked(out, start)
+	rb.mu.Unlock()
+
+	if n > 0 {
+		if err := rb.readDisk(disk, out[:n], start); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Oldest returns the absolute stream offset of the oldest byte still held,
+// in memory or on the disk tier.
+func (rb *RingBuffer) Oldest() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.oldestLocked()
+}
+
+func (rb *RingBuffer) oldestLocked() int {
+	if rb.disk != nil {
+		return rb.diskStart
+	}
+	return rb.memOldestLocked()
+}
+
+// memOldestLocked returns the offset of the oldest byte held in memory.
+func (rb *RingBuffer) memOldestLocked() int {
+	return max(rb.written-rb.capacity, 0)
+}
+
+// readLocked fills out with the audio starting at the absolute offset, which the
+// caller has checked is held, except for the first diskN bytes: those are on
+// the returned disk tier and must be read with readDisk after releasing rb.mu.
+// Must hold rb.mu.
+func (rb *RingBuffer) readLocked(out []byte, offset int) (disk *DiskTier, diskN int) {
+	if offset < rb.spillStart && rb.disk != nil {
+		disk, diskN = rb.disk, min(len(out), rb.spillStart-offset)
+		out = out[diskN:]
+		offset += diskN
+	}
+
+	// Queued for the disk but no longer in memory.
+	if memOldest := rb.memOldestLocked(); offset < memOldest && len(out) > 0 {
+		i := offset - rb.spillStart
+		n := copy(out, rb.flushing[min(i, len(rb.flushing)):])
+		n += copy(out[n:], rb.spill[max(i-len(rb.flushing), 0):])
+		out = out[n:]
+		offset += n
+	}
+
+	n := len(out)
+	pos := offset % rb.capacity
+	if pos+n <= rb.capacity {
+		copy(out, rb.buf[pos:pos+n])
+	} else {
+		first := rb.capacity - pos
+		copy(out[:first], rb.buf[pos:])
+		copy(out[first:], rb.buf[:n-first])
+	}
+	return disk, diskN
+}
+
+// readDisk reads the part of a range readLocked left on the disk tier, without
+// holding rb.mu. An I/O error drops the tier.
+func (rb *RingBuffer) readDisk(t *DiskTier, dst []byte, offset int) error {
+	err := t.read(dst, offset)
+	if err != nil && !errors.Is(err, ErrRangeOverwritten) {
+		rb.mu.Lock()
+		if rb.disk == t {
+			rb.dropDiskLocked(err)
+		}
+		rb.mu.Unlock()
+	}
+	return err
+}
+
+// Written returns the total number of bytes ever written.
+// It doubles as the absolute stream offset of the next byte to be written.
+func (rb *RingBuffer) Written() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.written
+}
+
+// ReadSince copies audio starting at the absolute stream offset into dst,
+// up to len(dst) bytes or the current write position, whichever comes first.
+// If offset has already been overwritten, reading starts at the oldest byte
+// still held. Returns the copied data and the offset it actually starts at.
+func (rb *RingBuffer) ReadSince(offset int, dst []byte) ([]byte, int) {
+	rb.mu.Lock()
+	if offset < rb.oldestLocked() {
+		offset = rb.oldestLocked()
+	}
+	if offset >= rb.written {
+		rb.mu.Unlock()
+		return nil, rb.written
+	}
+
+	n := rb.written - offset
+	if n > len(dst) {
+		n = len(dst)
+	}
+	out := dst[:n]
+	disk, diskN := rb.readLocked(out, offset)
+	rb.mu.Unlock()
+
+	if diskN > 0 {
+		if err := rb.readDisk(disk, out[:diskN], offset); err != nil {
+			// That audio is gone from disk; return what followed it.
+			return out[diskN:], offset + diskN
+		}
+	}
+	return out, offset
+}
+
 // CapacitySeconds returns the maximum duration the buffer can hold.
 // Safe to call without locking — capacity is immutable after construction.
 func (rb *RingBuffer) CapacitySeconds() float64 {
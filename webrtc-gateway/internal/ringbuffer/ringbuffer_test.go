@@ -2,7 +2,9 @@ package ringbuffer
 
 import (
 	"bytes"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestNewCapacity(t *testing.T) {
@@ -95,3 +97,327 @@ func TestAvailable(t *testing.T) {
 		t.Errorf("expected 5.0 available (capped), got %f", rb.Available())
 	}
 }
+
+func TestReadSince(t *testing.T) {
+	rb := New(1)
+	data := make([]byte, BytesPerSecond/2)
+	for i := range data {
+		data[i] = byte(i % 256)
+	}
+	rb.Write(data)
+
+	mark := rb.Written()
+	if mark != len(data) {
+		t.Fatalf("expected Written %d, got %d", len(data), mark)
+	}
+
+	if got, start := rb.ReadSince(mark, make([]byte, 100)); got != nil || start != mark {
+		t.Errorf("expected nothing new at write position, got %d bytes from %d", len(got), start)
+	}
+
+	more := []byte{1, 2, 3, 4}
+	rb.Write(more)
+	got, start := rb.ReadSince(mark, make([]byte, 100))
+	if start != mark || !bytes.Equal(got, more) {
+		t.Errorf("expected %v from %d, got %v from %d", more, mark, got, start)
+	}
+
+	// dst bounds the read
+	got, _ = rb.ReadSince(0, make([]byte, 10))
+	if !bytes.Equal(got, data[:10]) {
+		t.Errorf("expected first 10 bytes, got %v", got)
+	}
+}
+
+func TestReadSinceOverwritten(t *testing.T) {
+	rb := New(1)
+	rb.Write(make([]byte, BytesPerSecond))
+	tail := []byte{0xAA, 0xBB}
+	rb.Write(tail)
+
+	// Offset 0 has been overwritten: read starts at the oldest byte still held.
+	got, start := rb.ReadSince(0, make([]byte, BytesPerSecond))
+	if start != len(tail) {
+		t.Errorf("expected start clamped to %d, got %d", len(tail), start)
+	}
+	if len(got) != BytesPerSecond || !bytes.Equal(got[len(got)-2:], tail) {
+		t.Errorf("expected a full buffer ending in %v, got %d bytes", tail, len(got))
+	}
+}
+
+// nonZeroDetector treats any frame containing a non-zero byte as speech.
+type nonZeroDetector struct{}
+
+func (nonZeroDetector) IsSpeech(frame []byte) bool {
+	for _, b := range frame {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSpeechRegions(t *testing.T) {
+	rb := New(5)
+	rb.SetActivityDetector(nonZeroDetector{})
+
+	silence := make([]byte, activityFrameBytes*10)
+	speech := bytes.Repeat([]byte{1}, activityFrameBytes*5)
+
+	rb.Write(silence)
+	rb.Write(speech)
+	rb.Write(silence)
+	rb.Write(speech)
+	rb.Write(speech) // contiguous with the previous write: same region
+	rb.Write(silence)
+
+	got := rb.Utterances(10)
+	want := []Region{
+		{Start: len(silence), End: len(silence) + len(speech)},
+		{Start: 2*len(silence) + len(speech), End: 2*len(silence) + 3*len(speech)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d regions, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("region %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	if last := rb.Utterances(1); len(last) != 1 || last[0] != want[1] {
+		t.Errorf("expected last utterance %v, got %v", want[1], last)
+	}
+
+	start, end, ok := rb.SpeechBounds(0, rb.Written(), 100)
+	if !ok || start != want[0].Start-100 || end != want[1].End+100 {
+		t.Errorf("unexpected speech bounds %d-%d (ok=%v)", start, end, ok)
+	}
+
+	if _, _, ok := rb.SpeechBounds(0, len(silence), 0); ok {
+		t.Error("expected no speech in leading silence")
+	}
+}
+
+func TestSpeechRegionsPruned(t *testing.T) {
+	rb := New(1)
+	rb.SetActivityDetector(nonZeroDetector{})
+
+	rb.Write(bytes.Repeat([]byte{1}, activityFrameBytes))
+	rb.Write(make([]byte, BytesPerSecond))
+
+	if got := rb.Utterances(10); len(got) != 0 {
+		t.Errorf("expected overwritten speech to be evicted, got %v", got)
+	}
+}
+
+func TestSnapshotRange(t *testing.T) {
+	rb := New(1)
+
+	data := make([]byte, BytesPerSecond+BytesPerSecond/2)
+	for i := range data {
+		data[i] = byte(i / 32) // changes every ms
+	}
+	rb.Write(data)
+
+	dst := make([]byte, BytesPerSecond)
+	start, end := OffsetForMs(700), OffsetForMs(1200)
+	got, err := rb.SnapshotRange(start, end, dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, data[start:end]) {
+		t.Error("range content mismatch")
+	}
+
+	if rb.Oldest() != BytesPerSecond/2 {
+		t.Errorf("expected oldest %d, got %d", BytesPerSecond/2, rb.Oldest())
+	}
+	if _, err := rb.SnapshotRange(OffsetForMs(400), OffsetForMs(900), dst); err != ErrRangeOverwritten {
+		t.Errorf("expected ErrRangeOverwritten, got %v", err)
+	}
+	if _, err := rb.SnapshotRange(OffsetForMs(1400), OffsetForMs(1600), dst); err != ErrRangeNotWritten {
+		t.Errorf("expected ErrRangeNotWritten, got %v", err)
+	}
+}
+
+func TestTimeAt(t *testing.T) {
+	rb := New(10)
+	if !rb.TimeAt(0).IsZero() {
+		t.Error("expected zero time for empty buffer")
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	frame := make([]byte, 640) // 20ms
+
+	// One second of contiguous audio, with small jitter that must not add marks.
+	for i := 0; i < 50; i++ {
+		jitter := time.Duration(i%3) * 5 * time.Millisecond
+		rb.WriteAt(frame, base.Add(time.Duration(i)*20*time.Millisecond+jitter))
+	}
+	// A 2s capture gap (e.g. muted mic), then another second.
+	gapStart := base.Add(3 * time.Second)
+	for i := 0; i < 50; i++ {
+		rb.WriteAt(frame, gapStart.Add(time.Duration(i)*20*time.Millisecond))
+	}
+
+	if len(rb.timeline) != 2 {
+		t.Fatalf("expected 2 timeline marks, got %d", len(rb.timeline))
+	}
+
+	tests := []struct {
+		offset int
+		want   time.Time
+	}{
+		{0, base},
+		{BytesPerSecond / 2, base.Add(500 * time.Millisecond)},
+		{BytesPerSecond, gapStart},
+		{BytesPerSecond + BytesPerSecond/4, gapStart.Add(250 * time.Millisecond)},
+		{2 * BytesPerSecond, gapStart.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		if got := rb.TimeAt(tt.offset); !got.Equal(tt.want) {
+			t.Errorf("TimeAt(%d): expected %v, got %v", tt.offset, tt.want, got)
+		}
+	}
+
+	tl := rb.Timeline(BytesPerSecond/2, BytesPerSecond/2+640)
+	if len(tl) != 1 {
+		t.Errorf("expected only the covering mark, got %d", len(tl))
+	}
+	if got := tl.TimeAt(BytesPerSecond / 2); !got.Equal(base.Add(500 * time.Millisecond)) {
+		t.Errorf("unexpected timeline time %v", got)
+	}
+}
+
+func TestTimelinePruned(t *testing.T) {
+	rb := New(1)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	chunk := make([]byte, BytesPerSecond/2)
+
+	// Every write is discontinuous, so each adds a mark.
+	for i := 0; i < 6; i++ {
+		rb.WriteAt(chunk, base.Add(time.Duration(i)*time.Second))
+	}
+	if len(rb.timeline) != 2 {
+		t.Errorf("expected marks for overwritten audio to be pruned, got %d", len(rb.timeline))
+	}
+	if got, want := rb.TimeAt(rb.Oldest()), base.Add(4*time.Second); !got.Equal(want) {
+		t.Errorf("expected oldest byte at %v, got %v", want, got)
+	}
+}
+
+func TestDiskTier(t *testing.T) {
+	dir := t.TempDir() + "/dvr"
+	tier, err := NewDiskTier(dir, 3, 1)
+	if err != nil {
+		t.Fatalf("NewDiskTier: %v", err)
+	}
+
+	rb := New(1)
+	rb.SetDiskTier(tier, func(err error) { t.Errorf("unexpected disk error: %v", err) })
+
+	// 5.5s through a 1s memory buffer: 4.5s spilled, at least 3s retained on disk.
+	data := make([]byte, 5*BytesPerSecond+BytesPerSecond/2)
+	for i := range data {
+		data[i] = byte(i / 32)
+	}
+	for off := 0; off < len(data); off += 640 {
+		rb.Write(data[off : off+640])
+	}
+	waitSpilled(t, rb)
+
+	oldest := rb.Oldest()
+	if oldest != BytesPerSecond {
+		t.Errorf("expected disk to retain from %d, got %d", BytesPerSecond, oldest)
+	}
+
+	// A range spanning disk and memory.
+	dst := make([]byte, 2*BytesPerSecond)
+	start, end := OffsetForMs(3700), OffsetForMs(5200)
+	got, err := rb.SnapshotRange(start, end, dst)
+	if err != nil {
+		t.Fatalf("SnapshotRange: %v", err)
+	}
+	if !bytes.Equal(got, data[start:end]) {
+		t.Error("range spanning disk and memory mismatched")
+	}
+
+	got, from := rb.ReadSince(0, dst)
+	if from != oldest || !bytes.Equal(got, data[oldest:oldest+len(dst)]) {
+		t.Errorf("ReadSince from disk: got offset %d", from)
+	}
+
+	if _, err := rb.SnapshotRange(0, BytesPerSecond, dst); err != ErrRangeOverwritten {
+		t.Errorf("expected ErrRangeOverwritten past retention, got %v", err)
+	}
+
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected DVR directory removed, stat err = %v", err)
+	}
+	if rb.Oldest() != len(data)-BytesPerSecond {
+		t.Errorf("expected memory-only oldest after Close, got %d", rb.Oldest())
+	}
+}
+
+// waitSpilled waits for the disk writer to drain the spill queue.
+func waitSpilled(t *testing.T, rb *RingBuffer) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rb.mu.Lock()
+		queued := len(rb.flushing) + len(rb.spill)
+		rb.mu.Unlock()
+		if queued == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d bytes still queued for disk", queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDiskTierSlowDisk(t *testing.T) {
+	tier, err := NewDiskTier(t.TempDir()+"/dvr", 10, 1)
+	if err != nil {
+		t.Fatalf("NewDiskTier: %v", err)
+	}
+	rb := New(1)
+	rb.SetDiskTier(tier, func(err error) { t.Errorf("unexpected disk error: %v", err) })
+	defer rb.Close()
+
+	data := make([]byte, 3*BytesPerSecond)
+	for i := range data {
+		data[i] = byte(i / 32)
+	}
+
+	// A stalled disk must not block writes or reads of queued audio.
+	tier.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for off := 0; off < len(data); off += 640 {
+			rb.Write(data[off : off+640])
+		}
+		got, err := rb.SnapshotRange(0, len(data), make([]byte, len(data)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("SnapshotRange of queued audio: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("write blocked on the disk")
+	}
+	tier.mu.Unlock()
+
+	waitSpilled(t, rb)
+	got, err := rb.SnapshotRange(0, len(data), make([]byte, len(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("SnapshotRange from disk: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -25,32 +26,78 @@ type Session struct {
 
 	mu         sync.Mutex
 	pc         *webrtc.PeerConnection
-	dc         *webrtc.DataChannel
+	dc         MessageSender
 	audioTrack *webrtc.TrackLocalStaticSample
 	decoder    *audio.Decoder
 	encoder    *audio.Encoder
+	mixer      *audio.Mixer // outbound audio, encoded by the outbound loop
 	router     *datachannel.Router
 	logger     *zap.Logger
 	stopCh     chan struct{}
 	stopped    bool
 
 	activeAction string
-	actionCancel context.CancelFunc
+	actionCancel context.CancelCauseFunc
+	cancelled    bool                   // the active action was cancelled by CancelAction
+	actionTTS    datachannel.TTSOptions // effective TTS options of the active action
+	synthSpeed   float64                // speed the active action's audio was synthesized at (0 = not yet)
+	playStart    time.Time              // when the active action's audio started playing
+	rateChanges  []rateChange           // playback rate changes of the active action
+	actionMarks  *datachannel.EventTtsMarks
+
+	defaults Defaults
+
+	asrPreprocess audio.PreprocessOptions // applied to snapshots before ASR
+	ttsLoudness   *audio.LoudnessTarget   // TTS playback level; nil disables normalization
+
+	captionCancel context.CancelFunc
+
+	bookmarks []Bookmark // oldest first
 
 	ingestSource ingest.Source
-	ingestActive bool // when true, mic RTP writes to ring buffer are suppressed
+	ingestActive bool              // when true, mic RTP writes to ring buffer are suppressed
+	ingestRelay  *audio.MixerInput // ingest audio played to the client, if relayed
+
+	lastSeqNum  uint16
+	seqNumInit  bool
+	inResampler *audio.Resampler // 48k → 16k, owned by the inbound RTP loop
 
-	lastSeqNum uint16
-	seqNumInit bool
+	// RTP clock → wall clock mapping for ring buffer capture times.
+	rtpBaseWall time.Time
+	rtpLastTS   uint32
+	rtpElapsed  int64 // RTP ticks since rtpBaseWall, unwrapped
+}
+
+// rtpReanchorThreshold is how far the RTP-derived capture time may drift from
+// packet arrival before the mapping is reset (sender clock reset or drift).
+const rtpReanchorThreshold = 2 * time.Second
+
+// DefaultDuckDB is how far a relayed ingest source is attenuated under TTS.
+const DefaultDuckDB = -15.0
+
+// ErrActionCancelled is the context cause of an action stopped by command.cancel.
+var ErrActionCancelled = errors.New("action cancelled by client")
+
+// Defaults holds session-level parameters set via command.update.
+// They apply to subsequent enunciates that leave the corresponding fields empty.
+type Defaults struct {
+	TargetLanguage string
+	TTSOptions     datachannel.TTSOptions
 }
 
 // New creates a new session with a ring buffer of the specified duration.
+// Inbound audio is tagged for speech by a VAD as it is written.
 func New(id string, ringBufferSeconds int, logger *zap.Logger) *Session {
+	rb := ringbuffer.New(ringBufferSeconds)
+	rb.SetActivityDetector(audio.NewVAD())
 	return &Session{
-		ID:         id,
-		RingBuffer: ringbuffer.New(ringBufferSeconds),
-		logger:     logger.With(zap.String("session", id)),
-		stopCh:     make(chan struct{}),
+		ID:            id,
+		RingBuffer:    rb,
+		logger:        logger.With(zap.String("session", id)),
+		stopCh:        make(chan struct{}),
+		mixer:         audio.NewMixer(DefaultDuckDB),
+		inResampler:   audio.NewResampler(audio.OpusSampleRate, audio.PCMSampleRate),
+		asrPreprocess: audio.DefaultPreprocessOptions,
 	}
 }
 
@@ -62,6 +109,21 @@ func (s *Session) SetPeerConnection(pc *webrtc.PeerConnection, track *webrtc.Tra
 }
 
 func (s *Session) SetDataChannel(dc *webrtc.DataChannel) {
+	if dc == nil {
+		s.SetMessageSender(nil)
+		return
+	}
+	s.SetMessageSender(dc)
+}
+
+// MessageSender delivers data channel messages. *webrtc.DataChannel is one.
+type MessageSender interface {
+	SendText(text string) error
+}
+
+// SetMessageSender sets where SendDataChannelMessage delivers, in place of a
+// WebRTC data channel (e.g. to record events in tests).
+func (s *Session) SetMessageSender(dc MessageSender) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.dc = dc
@@ -74,6 +136,34 @@ func (s *Session) SetCodecs(dec *audio.Decoder, enc *audio.Encoder) {
 	s.encoder = enc
 }
 
+// SetASRPreprocessing sets how audio snapshots are conditioned before ASR.
+func (s *Session) SetASRPreprocessing(opts audio.PreprocessOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.asrPreprocess = opts
+}
+
+// ASRPreprocessing returns the session's ASR preprocessing options.
+func (s *Session) ASRPreprocessing() audio.PreprocessOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.asrPreprocess
+}
+
+// SetTTSLoudness sets the loudness TTS playback is normalized to; nil disables
+// normalization (volume still applies).
+func (s *Session) SetTTSLoudness(target *audio.LoudnessTarget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttsLoudness = target
+}
+
+// SetDuckLevel sets how far a relayed ingest source is attenuated (dB, negative)
+// while TTS plays.
+func (s *Session) SetDuckLevel(duckDB float64) {
+	s.mixer.SetDuckLevel(duckDB)
+}
+
 func (s *Session) SetRouter(r *datachannel.Router) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -95,6 +185,13 @@ func (s *Session) SetIngestSource(src ingest.Source) {
 	}
 }
 
+// IngestSource returns the current ingest source, or nil.
+func (s *Session) IngestSource() ingest.Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ingestSource
+}
+
 // IngestStatus returns the status of the current ingest source, or nil.
 func (s *Session) IngestStatus() *ingest.Status {
 	s.mu.Lock()
@@ -112,13 +209,353 @@ func (s *Session) IngestStatus() *ingest.Status {
 func (s *Session) StopIngest() {
 	s.mu.Lock()
 	src := s.ingestSource
+	relay := s.ingestRelay
 	s.ingestSource = nil
 	s.ingestActive = false
+	s.ingestRelay = nil
 	s.mu.Unlock()
 
 	if src != nil {
 		src.Stop()
 	}
+	if relay != nil {
+		relay.Close()
+	}
+}
+
+// NewIngestRelay returns a relay that plays ingested audio to the client on the
+// outbound track, ducked under TTS. It replaces any previous relay and is
+// detached by StopIngest.
+func (s *Session) NewIngestRelay() ingest.Relay {
+	in := s.mixer.AddInput(audio.Background)
+	s.mu.Lock()
+	old := s.ingestRelay
+	s.ingestRelay = in
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return &ingestRelay{
+		in:        in,
+		resampler: audio.NewResampler(audio.PCMSampleRate, audio.OpusSampleRate),
+	}
+}
+
+// ingestRelay upsamples ingested 16kHz PCM into a background mixer input.
+type ingestRelay struct {
+	in        *audio.MixerInput
+	resampler *audio.Resampler
+	odd       []byte // trailing byte of a sample split across writes
+}
+
+func (r *ingestRelay) Write(ctx context.Context, pcm []byte) error {
+	if len(r.odd) > 0 {
+		pcm = append(r.odd, pcm...)
+		r.odd = nil
+	}
+	if len(pcm)%2 == 1 {
+		r.odd = []byte{pcm[len(pcm)-1]}
+		pcm = pcm[:len(pcm)-1]
+	}
+	return r.in.Write(ctx, r.resampler.Process(audio.BytesToInt16(pcm)))
+}
+
+// Defaults returns the current session-level defaults.
+func (s *Session) Defaults() Defaults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaults
+}
+
+// UpdateDefaults changes session-level defaults and returns the result.
+// A nil targetLanguage leaves it unchanged; an empty string clears it.
+// Empty TTS fields leave the corresponding default unchanged.
+func (s *Session) UpdateDefaults(targetLanguage *string, tts datachannel.TTSOptions) Defaults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if targetLanguage != nil {
+		s.defaults.TargetLanguage = *targetLanguage
+	}
+	s.defaults.TTSOptions = mergeTTSOptions(s.defaults.TTSOptions, tts)
+	return s.defaults
+}
+
+// SetActionTTSOptions records the TTS options an action was started with.
+// No-op if actionID is not the active action.
+func (s *Session) SetActionTTSOptions(actionID string, opts datachannel.TTSOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeAction == actionID {
+		s.actionTTS = opts
+	}
+}
+
+// ActionUpdate is the outcome of a mid-action TTS change.
+type ActionUpdate struct {
+	// TTSOptions are the options now in effect.
+	TTSOptions datachannel.TTSOptions
+	// NotApplied names the requested fields that could not change because
+	// synthesis had already started ("voice", "streaming").
+	NotApplied []string
+	// Marks are the action's word marks rescheduled for a new playback rate,
+	// when marks have been sent and the rate changed.
+	Marks *datachannel.EventTtsMarks
+}
+
+// rateChange records that from synthMs into the synthesized audio, played at
+// playMs after playback started, the playback rate became rate.
+type rateChange struct {
+	synthMs, playMs, rate float64
+}
+
+// UpdateActionTTSOptions applies a mid-action TTS change to the active action.
+// If synthesis has not started yet the new options are used for it. Once it
+// has, a speed change alters the playback rate of the audio (pitch is kept)
+// and a volume change applies from the next chunk; voice and streaming can
+// no longer change and are reported in NotApplied.
+// Returns false if actionID is not the active action.
+func (s *Session) UpdateActionTTSOptions(actionID string, opts datachannel.TTSOptions) (ActionUpdate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actionID == "" || s.activeAction != actionID {
+		return ActionUpdate{}, false
+	}
+
+	var update ActionUpdate
+	if s.synthSpeed > 0 {
+		if opts.Voice != "" && opts.Voice != s.actionTTS.Voice {
+			update.NotApplied = append(update.NotApplied, "voice")
+		}
+		if opts.Streaming != nil && opts.IsStreaming() != s.actionTTS.IsStreaming() {
+			update.NotApplied = append(update.NotApplied, "streaming")
+		}
+		opts.Voice, opts.Streaming = "", nil
+	}
+
+	oldRate := s.playbackRateLocked()
+	s.actionTTS = mergeTTSOptions(s.actionTTS, opts)
+	if rate := s.playbackRateLocked(); rate != oldRate {
+		s.changeRateLocked(rate)
+		if s.actionMarks != nil {
+			m := s.scheduleMarksLocked(*s.actionMarks)
+			update.Marks = &m
+		}
+	}
+	update.TTSOptions = s.actionTTS
+	return update, true
+}
+
+// changeRateLocked records a playback rate change at the current playback
+// position. Must hold s.mu.
+func (s *Session) changeRateLocked(rate float64) {
+	last := rateChange{rate: 1}
+	if n := len(s.rateChanges); n > 0 {
+		last = s.rateChanges[n-1]
+	}
+	playMs := last.playMs
+	if !s.playStart.IsZero() {
+		playMs = max(playMs, float64(time.Since(s.playStart).Microseconds())/1000)
+	}
+	s.rateChanges = append(s.rateChanges, rateChange{
+		synthMs: last.synthMs + (playMs-last.playMs)*last.rate,
+		playMs:  playMs,
+		rate:    rate,
+	})
+}
+
+// playMsLocked maps a time in the synthesized audio to when it is played,
+// relative to the start of playback. Must hold s.mu.
+func (s *Session) playMsLocked(synthMs float64) float64 {
+	c := rateChange{rate: 1}
+	for _, rc := range s.rateChanges {
+		if rc.synthMs <= synthMs {
+			c = rc
+		}
+	}
+	return c.playMs + (synthMs-c.synthMs)/c.rate
+}
+
+// ScheduleMarks records the active action's word marks, timed in synthesized
+// audio, and returns them timed as played: later words move when the playback
+// rate is changed mid-action.
+func (s *Session) ScheduleMarks(actionID string, marks datachannel.EventTtsMarks) datachannel.EventTtsMarks {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeAction != actionID {
+		return marks
+	}
+	s.actionMarks = &marks
+	return s.scheduleMarksLocked(marks)
+}
+
+func (s *Session) scheduleMarksLocked(marks datachannel.EventTtsMarks) datachannel.EventTtsMarks {
+	words := make([]datachannel.WordMark, len(marks.Words))
+	for i, w := range marks.Words {
+		words[i] = datachannel.WordMark{Word: w.Word, StartMs: s.playMsLocked(w.StartMs), EndMs: s.playMsLocked(w.EndMs)}
+	}
+	marks.Words = words
+	marks.DurationMs = s.playMsLocked(marks.DurationMs)
+	return marks
+}
+
+// markPlaying records when the active action's audio started playing.
+func (s *Session) markPlaying() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeAction != "" && s.playStart.IsZero() {
+		s.playStart = time.Now()
+	}
+}
+
+// BeginSynthesis returns the latest TTS options for the action (reflecting any
+// mid-action updates) and records the speed its audio is synthesized at.
+func (s *Session) BeginSynthesis(actionID string, opts datachannel.TTSOptions) datachannel.TTSOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeAction != actionID {
+		return opts
+	}
+	s.actionTTS = mergeTTSOptions(opts, s.actionTTS)
+	s.synthSpeed = effectiveSpeed(s.actionTTS.Speed)
+	return s.actionTTS
+}
+
+// playbackRate is the ratio between the requested and synthesized TTS speed
+// of the active action. 1.0 unless the speed was changed mid-playback.
+func (s *Session) playbackRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.playbackRateLocked()
+}
+
+func (s *Session) playbackRateLocked() float64 {
+	if s.synthSpeed <= 0 {
+		return 1.0
+	}
+	return effectiveSpeed(s.actionTTS.Speed) / s.synthSpeed
+}
+
+// playbackVolume is the linear gain requested for the active action's audio.
+func (s *Session) playbackVolume() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.actionTTS.Volume <= 0 {
+		return 1.0
+	}
+	return s.actionTTS.Volume
+}
+
+func mergeTTSOptions(base, update datachannel.TTSOptions) datachannel.TTSOptions {
+	if update.Voice != "" {
+		base.Voice = update.Voice
+	}
+	if update.Speed > 0 {
+		base.Speed = update.Speed
+	}
+	if update.Volume > 0 {
+		base.Volume = update.Volume
+	}
+	if update.Streaming != nil {
+		base.Streaming = update.Streaming
+	}
+	return base
+}
+
+func effectiveSpeed(speed float64) float64 {
+	if speed <= 0 {
+		return 1.0
+	}
+	return speed
+}
+
+// StartCaptions marks live captioning as running and returns a context that is
+// cancelled by StopCaptions or when the session stops.
+// Returns false if captioning is already running or the session has stopped.
+func (s *Session) StartCaptions() (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped || s.captionCancel != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.captionCancel = cancel
+	return ctx, true
+}
+
+// StopCaptions stops live captioning. Returns false if it was not running.
+func (s *Session) StopCaptions() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.captionCancel == nil {
+		return false
+	}
+	s.captionCancel()
+	s.captionCancel = nil
+	return true
+}
+
+// maxBookmarks bounds the bookmarks kept per session; the oldest is dropped first.
+const maxBookmarks = 64
+
+// Bookmark marks a point on the session's audio timeline.
+type Bookmark struct {
+	ID     string
+	Label  string
+	Offset int       // absolute ring buffer stream offset
+	At     time.Time // capture time of the audio at Offset
+}
+
+// AddBookmark records a bookmark at the current ring buffer write position.
+// A bookmark with the same ID is replaced.
+func (s *Session) AddBookmark(id, label string) Bookmark {
+	offset := s.RingBuffer.Written()
+	bm := Bookmark{
+		ID:     id,
+		Label:  label,
+		Offset: offset,
+		At:     s.RingBuffer.TimeAt(offset),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictBookmarksLocked()
+	for i, b := range s.bookmarks {
+		if b.ID == id {
+			s.bookmarks = append(s.bookmarks[:i], s.bookmarks[i+1:]...)
+			break
+		}
+	}
+	s.bookmarks = append(s.bookmarks, bm)
+	if len(s.bookmarks) > maxBookmarks {
+		s.bookmarks = s.bookmarks[1:]
+	}
+	return bm
+}
+
+// Bookmark returns the bookmark with the given ID. Bookmarks whose audio has
+// been overwritten in the ring buffer are evicted and no longer found.
+func (s *Session) Bookmark(id string) (Bookmark, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictBookmarksLocked()
+	for _, b := range s.bookmarks {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// evictBookmarksLocked drops bookmarks older than the oldest buffered audio.
+// Bookmarks are kept in offset order, so only a prefix can expire. Must hold s.mu.
+func (s *Session) evictBookmarksLocked() {
+	oldest := s.RingBuffer.Oldest()
+	i := 0
+	for i < len(s.bookmarks) && s.bookmarks[i].Offset < oldest {
+		i++
+	}
+	s.bookmarks = s.bookmarks[i:]
 }
 
 // TryStartAction attempts to claim the session for an action.
@@ -130,51 +567,155 @@ func (s *Session) TryStartAction(actionID string, timeout time.Duration) context
 
 	// Cancel any existing action
 	if s.actionCancel != nil {
-		s.actionCancel()
+		s.actionCancel(nil)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	base, cancelCause := context.WithCancelCause(context.Background())
+	ctx, cancelTimeout := context.WithTimeout(base, timeout)
 	s.activeAction = actionID
-	s.actionCancel = cancel
+	s.cancelled = false
+	s.actionCancel = func(cause error) {
+		cancelCause(cause)
+		cancelTimeout()
+	}
+	s.resetActionLocked()
 	return ctx
 }
 
+// CancelAction cancels the active action on client request, with ErrActionCancelled
+// as the context cause. An empty actionID cancels whatever action is active.
+// Returns the cancelled actionID, or false if no matching action is in flight
+// (including one already cancelled and still winding down).
+func (s *Session) CancelAction(actionID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeAction == "" || s.actionCancel == nil || s.cancelled {
+		return "", false
+	}
+	if actionID != "" && actionID != s.activeAction {
+		return "", false
+	}
+	s.actionCancel(ErrActionCancelled)
+	s.cancelled = true
+	return s.activeAction, true
+}
+
 // FinishAction clears the active action if it matches the given actionID.
 func (s *Session) FinishAction(actionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.activeAction == actionID {
 		if s.actionCancel != nil {
-			s.actionCancel() // release timeout resources
+			s.actionCancel(nil) // release timeout resources
 		}
 		s.activeAction = ""
 		s.actionCancel = nil
+		s.resetActionLocked()
 	}
 }
 
-// PlayPCMStream reads 16kHz PCM s16le chunks from the channel, upsamples to 48kHz,
-// encodes to Opus, and writes to the outbound WebRTC track at real-time pace.
-func (s *Session) PlayPCMStream(ctx context.Context, chunks <-chan []byte) error {
+// resetActionLocked clears the per-action TTS state. Must hold s.mu.
+func (s *Session) resetActionLocked() {
+	s.actionTTS = datachannel.TTSOptions{}
+	s.synthSpeed = 0
+	s.playStart = time.Time{}
+	s.rateChanges = nil
+	s.actionMarks = nil
+}
+
+// StartOutbound starts the loop that mixes outbound audio (TTS, test tone and a
+// relayed ingest source), encodes it with the session's Opus encoder and writes
+// it to the outbound track at real-time pace. It runs until the session stops.
+func (s *Session) StartOutbound() {
 	s.mu.Lock()
 	enc := s.encoder
 	track := s.audioTrack
 	s.mu.Unlock()
 
 	if enc == nil || track == nil {
-		return fmt.Errorf("encoder or track not set")
+		s.logger.Warn("cannot start outbound audio: encoder or track not set")
+		return
 	}
+	go s.outboundLoop(enc, track)
+}
 
+func (s *Session) outboundLoop(enc *audio.Encoder, track *webrtc.TrackLocalStaticSample) {
 	frameDuration := time.Duration(audio.FrameDurationMs) * time.Millisecond
-	// 320 samples at 16kHz = 20ms (one Opus frame after upsample to 960 at 48kHz)
-	samplesPerFrame16k := audio.SamplesPerFrame / 3
-
-	// Pre-allocate upsample buffer (reused across frames, lifetime matches this goroutine)
-	upsampleBuf := make([]int16, audio.SamplesPerFrame)
+	frame := make([]int16, audio.SamplesPerFrame)
 
 	// Pre-allocate encode buffer (reused across frames)
 	encodeBuf := make([]byte, 1024)
 
-	var residual []int16
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+
+	for {
+		if !s.mixer.Mix(frame) {
+			// Nothing to play: send nothing until audio is written.
+			select {
+			case <-s.mixer.Ready():
+			case <-s.stopCh:
+				return
+			}
+			ticker.Reset(frameDuration)
+			select {
+			case <-ticker.C: // drop a tick left from before the pause
+			default:
+			}
+			continue
+		}
+
+		opusData, err := enc.EncodeInto(frame, encodeBuf)
+		if err != nil {
+			s.logger.Warn("opus encode failed", zap.Error(err))
+			metrics.EncodeErrorsTotal.Inc()
+		} else {
+			// Copy for WriteSample (pion may retain the slice)
+			sampleData := make([]byte, len(opusData))
+			copy(sampleData, opusData)
+			if err := track.WriteSample(media.Sample{
+				Data:     sampleData,
+				Duration: frameDuration,
+			}); err != nil {
+				s.logger.Warn("write sample failed", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// PlayAudioStream reads TTS chunks from the channel, converts them from their
+// declared format to 48kHz mono and plays them through the outbound mix,
+// ducking a relayed ingest source. The format may change between chunks.
+// Returns once the audio has been played. Cancelling ctx stops playback at the
+// next 20ms frame boundary.
+// Playback is normalized to the session's TTS loudness target and scaled by the
+// action's volume. A mid-action speed or volume change (UpdateActionTTSOptions)
+// is applied from the next chunk.
+func (s *Session) PlayAudioStream(ctx context.Context, chunks <-chan audio.Chunk) error {
+	s.mu.Lock()
+	enc := s.encoder
+	track := s.audioTrack
+	loudness := s.ttsLoudness
+	s.mu.Unlock()
+
+	if enc == nil || track == nil {
+		return fmt.Errorf("encoder or track not set")
+	}
+
+	in := s.mixer.AddInput(audio.Foreground)
+	defer in.Close()
+
+	var conv *audio.PlaybackConverter
+	var speed audio.SpeedChanger
+	speedActive := false
+	playing := false
+	norm := audio.NewLoudnessNormalizer(audio.OpusSampleRate, loudness)
 
 	for {
 		select {
@@ -184,71 +725,68 @@ func (s *Session) PlayPCMStream(ctx context.Context, chunks <-chan []byte) error
 			return fmt.Errorf("session stopped")
 		case chunk, ok := <-chunks:
 			if !ok {
-				// Channel closed — drain residual if any
-				if len(residual) > 0 {
-					// Pad residual to full frame with zeros
-					for len(residual) < samplesPerFrame16k {
-						residual = append(residual, 0)
-					}
-					frame48k := audio.Upsample16to48Into(residual[:samplesPerFrame16k], upsampleBuf)
-					opusData, err := enc.EncodeInto(frame48k, encodeBuf)
-					if err == nil {
-						sampleData := make([]byte, len(opusData))
-						copy(sampleData, opusData)
-						track.WriteSample(media.Sample{
-							Data:     sampleData,
-							Duration: frameDuration,
-						})
+				if speedActive {
+					rest := speed.Flush()
+					norm.Process(rest, s.playbackVolume())
+					if err := s.waitPlayed(ctx, in.Write(ctx, rest)); err != nil {
+						return err
 					}
 				}
-				return nil
+				return s.waitPlayed(ctx, in.Drain(ctx))
 			}
-
-			samples16k := audio.BytesToInt16(chunk)
-			if len(residual) > 0 {
-				samples16k = append(residual, samples16k...)
-				residual = nil
+			if !playing {
+				playing = true
+				s.markPlaying()
 			}
 
-			// Process in frames of samplesPerFrame16k (320 samples = 20ms at 16kHz)
-			for len(samples16k) >= samplesPerFrame16k {
-				frame16k := samples16k[:samplesPerFrame16k]
-				samples16k = samples16k[samplesPerFrame16k:]
-
-				frame48k := audio.Upsample16to48Into(frame16k, upsampleBuf)
-				opusData, err := enc.EncodeInto(frame48k, encodeBuf)
+			if conv == nil || conv.Format() != chunk.Format {
+				c, err := audio.NewPlaybackConverter(chunk.Format)
 				if err != nil {
-					s.logger.Warn("opus encode failed in stream", zap.Error(err))
-					metrics.EncodeErrorsTotal.Inc()
-					continue
-				}
-
-				// Copy for WriteSample (pion may retain the slice)
-				sampleData := make([]byte, len(opusData))
-				copy(sampleData, opusData)
-
-				if err := track.WriteSample(media.Sample{
-					Data:     sampleData,
-					Duration: frameDuration,
-				}); err != nil {
-					return fmt.Errorf("write sample: %w", err)
+					return fmt.Errorf("tts audio format %s: %w", chunk.Format, err)
 				}
+				conv = c
+			}
+			samples48k, err := conv.Convert(chunk.Data)
+			if err != nil {
+				s.logger.Warn("tts audio conversion failed", zap.Error(err))
+				metrics.DecodeErrorsTotal.Inc()
+				continue
+			}
 
-				time.Sleep(frameDuration)
+			// Once the rate has changed keep the converter in the path so its
+			// interpolation state stays continuous, even if the rate returns to 1.0.
+			if rate := s.playbackRate(); rate != 1.0 || speedActive {
+				speedActive = true
+				samples48k = speed.Process(samples48k, rate)
 			}
+			norm.Process(samples48k, s.playbackVolume())
 
-			// Save leftover samples
-			if len(samples16k) > 0 {
-				residual = make([]int16, len(samples16k))
-				copy(residual, samples16k)
+			if err := s.waitPlayed(ctx, in.Write(ctx, samples48k)); err != nil {
+				return err
 			}
 		}
 	}
 }
 
+// waitPlayed maps the result of a mixer write or drain to a playback error,
+// reporting a stopped session (which closes the mixer inputs) as such.
+func (s *Session) waitPlayed(ctx context.Context, err error) error {
+	if err != nil {
+		return err
+	}
+	select {
+	case <-s.stopCh:
+		return fmt.Errorf("session stopped")
+	default:
+		return nil
+	}
+}
+
 // HandleInboundRTP decodes an Opus packet, downsamples 48k→16k, and writes to the ring buffer.
-// Detects sequence number gaps and applies PLC for missing frames.
-func (s *Session) HandleInboundRTP(seqNum uint16, opusData []byte) {
+// Detects sequence number gaps and applies PLC for missing frames. The RTP timestamp
+// gives the capture time recorded with the audio, so gaps in sending (e.g. DTX or a
+// muted mic) show up in the ring buffer timeline rather than compressing it.
+func (s *Session) HandleInboundRTP(seqNum uint16, rtpTimestamp uint32, opusData []byte) {
 	s.mu.Lock()
 	dec := s.decoder
 	ingestActive := s.ingestActive
@@ -264,7 +802,10 @@ func (s *Session) HandleInboundRTP(seqNum uint16, opusData []byte) {
 	bufs := audio.AcquireInboundBuffers()
 	defer audio.ReleaseInboundBuffers(bufs)
 
-	// Detect gaps in RTP sequence numbers for PLC
+	capturedAt := s.rtpCaptureTime(rtpTimestamp)
+
+	// Detect gaps in RTP sequence numbers. The frame just before this packet is
+	// recovered from its in-band FEC when present; anything earlier gets PLC.
 	if s.seqNumInit {
 		expected := s.lastSeqNum + 1
 		if seqNum != expected {
@@ -272,16 +813,26 @@ func (s *Session) HandleInboundRTP(seqNum uint16, opusData []byte) {
 			if gap > 0 && gap < 100 {
 				metrics.RTPGapsTotal.Inc()
 				for i := 0; i < gap; i++ {
-					plc, err := dec.DecodePLC(audio.OpusSampleRate * audio.FrameDurationMs / 1000)
+					var lost []int16
+					var err error
+					if i == gap-1 && audio.OpusPacketHasFEC(opusData) {
+						lost, err = s.decodeFEC(dec, opusData, bufs.DecodeBuf)
+					} else {
+						lost, err = dec.DecodePLC(audio.OpusSampleRate * audio.FrameDurationMs / 1000)
+						if err == nil {
+							metrics.RTPLostFramesTotal.WithLabelValues("plc").Inc()
+						}
+					}
 					if err != nil {
-						s.logger.Warn("PLC decode failed", zap.Error(err))
+						s.logger.Warn("lost frame decode failed", zap.Error(err))
 						metrics.DecodeErrorsTotal.Inc()
 						continue
 					}
-					down := audio.Downsample48to16Into(plc, bufs.DownsampleBuf)
+					down := s.inResampler.ProcessInto(lost, bufs.DownsampleBuf)
 					pcmBytes := audio.Int16ToBytesInto(down, bufs.BytesBuf)
 					if !ingestActive {
-						s.RingBuffer.Write(pcmBytes)
+						lostAt := capturedAt.Add(-time.Duration(gap-i) * audio.FrameDurationMs * time.Millisecond)
+						s.RingBuffer.WriteAt(pcmBytes, lostAt)
 					}
 				}
 			}
@@ -300,57 +851,61 @@ func (s *Session) HandleInboundRTP(seqNum uint16, opusData []byte) {
 	pcm48 := bufs.DecodeBuf[:n]
 
 	// Downsample 48kHz → 16kHz and write to ring buffer (suppressed during ingest)
-	pcm16 := audio.Downsample48to16Into(pcm48, bufs.DownsampleBuf)
+	pcm16 := s.inResampler.ProcessInto(pcm48, bufs.DownsampleBuf)
 	pcmBytes := audio.Int16ToBytesInto(pcm16, bufs.BytesBuf)
 	if !ingestActive {
-		s.RingBuffer.Write(pcmBytes)
+		s.RingBuffer.WriteAt(pcmBytes, capturedAt)
 	}
 }
 
-// PlayTestTone generates a sine wave, encodes it to Opus, and writes it to the outbound track.
-// Blocks for the duration of the tone.
-func (s *Session) PlayTestTone(durationSec float64) {
-	s.mu.Lock()
-	enc := s.encoder
-	track := s.audioTrack
-	s.mu.Unlock()
+// decodeFEC recovers the frame lost before opusData into buf, assuming it had the
+// same duration as opusData. Falls back to PLC if the decoder rejects the FEC data.
+func (s *Session) decodeFEC(dec *audio.Decoder, opusData []byte, buf []int16) ([]int16, error) {
+	n, err := audio.OpusPacketSamples(opusData)
+	if err == nil {
+		if n, err = dec.DecodeFECInto(opusData, buf[:n]); err == nil {
+			metrics.RTPLostFramesTotal.WithLabelValues("fec").Inc()
+			return buf[:n], nil
+		}
+	}
+	s.logger.Debug("FEC recovery failed, concealing", zap.Error(err))
+	pcm, err := dec.DecodePLC(audio.OpusSampleRate * audio.FrameDurationMs / 1000)
+	if err == nil {
+		metrics.RTPLostFramesTotal.WithLabelValues("plc").Inc()
+	}
+	return pcm, err
+}
 
-	if enc == nil || track == nil {
-		s.logger.Warn("cannot play test tone: encoder or track not set")
-		return
+// rtpCaptureTime maps an inbound RTP timestamp to wall-clock time, anchored on
+// the arrival time of the first packet. Only called from the inbound RTP loop.
+func (s *Session) rtpCaptureTime(ts uint32) time.Time {
+	now := time.Now()
+	if s.rtpBaseWall.IsZero() {
+		s.rtpBaseWall, s.rtpLastTS, s.rtpElapsed = now, ts, 0
+		return now
 	}
 
+	s.rtpElapsed += int64(int32(ts - s.rtpLastTS))
+	s.rtpLastTS = ts
+	at := s.rtpBaseWall.Add(time.Duration(s.rtpElapsed) * time.Second / audio.OpusSampleRate)
+	if d := now.Sub(at); d < -rtpReanchorThreshold || d > rtpReanchorThreshold {
+		s.rtpBaseWall, s.rtpElapsed = now, 0
+		return now
+	}
+	return at
+}
+
+// PlayTestTone generates a sine wave and plays it through the outbound mix.
+// Blocks for the duration of the tone.
+func (s *Session) PlayTestTone(durationSec float64) {
 	// Generate 16kHz sine wave and upsample to 48kHz for Opus encoding
 	pcm16 := audio.GenerateSineWave(durationSec, audio.ToneFrequency)
-	pcm48 := audio.Upsample16to48(pcm16)
-
-	frameDuration := time.Duration(audio.FrameDurationMs) * time.Millisecond
-
-	// Chunk into 960-sample (20ms) frames and encode/send
-	for i := 0; i+audio.SamplesPerFrame <= len(pcm48); i += audio.SamplesPerFrame {
-		select {
-		case <-s.stopCh:
-			return
-		default:
-		}
+	pcm48 := audio.NewResampler(audio.PCMSampleRate, audio.OpusSampleRate).Process(pcm16)
 
-		frame := pcm48[i : i+audio.SamplesPerFrame]
-		opusData, err := enc.Encode(frame)
-		if err != nil {
-			s.logger.Warn("opus encode failed", zap.Error(err))
-			return
-		}
-
-		if err := track.WriteSample(media.Sample{
-			Data:     opusData,
-			Duration: frameDuration,
-		}); err != nil {
-			s.logger.Warn("write sample failed", zap.Error(err))
-			return
-		}
-
-		time.Sleep(frameDuration)
-	}
+	in := s.mixer.AddInput(audio.Foreground)
+	defer in.Close()
+	in.Write(context.Background(), pcm48)
+	in.Drain(context.Background())
 }
 
 // SendDataChannelMessage sends a JSON message over the data channel.
@@ -385,18 +940,22 @@ func (s *Session) SetRemoteDescription(desc webrtc.SessionDescription) error {
 // Stop closes the session. Idempotent.
 func (s *Session) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if s.stopped {
+		s.mu.Unlock()
 		return
 	}
 	s.stopped = true
 
 	if s.actionCancel != nil {
-		s.actionCancel()
+		s.actionCancel(nil)
 		s.actionCancel = nil
 	}
 
+	if s.captionCancel != nil {
+		s.captionCancel()
+		s.captionCancel = nil
+	}
+
 	if s.ingestSource != nil {
 		s.ingestSource.Stop()
 		s.ingestSource = nil
@@ -404,9 +963,17 @@ func (s *Session) Stop() {
 	}
 
 	close(s.stopCh)
+	s.mixer.Close()
 
 	if s.pc != nil {
 		s.pc.Close()
 	}
+	s.mu.Unlock()
+
+	// Closing waits for the DVR files to be removed, which can take a while:
+	// done outside the lock so the session's other calls aren't held up.
+	if err := s.RingBuffer.Close(); err != nil {
+		s.logger.Warn("failed to remove DVR files", zap.Error(err))
+	}
 	s.logger.Info("session stopped")
 }
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/RenatoCabral2022/WhatsWebService/webrtc-gateway/internal/ringbuffer"
+)
+
+func newTestSession(t *testing.T) *Session {
+	t.Helper()
+	s := New("sess-1", 5, zap.NewNop())
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestBookmarkResolve(t *testing.T) {
+	s := newTestSession(t)
+	t0 := time.Unix(1700000000, 0)
+	second := make([]byte, ringbuffer.BytesPerSecond)
+	s.RingBuffer.WriteAt(second, t0)
+	s.RingBuffer.WriteAt(second, t0.Add(time.Second))
+
+	added := s.AddBookmark("b1", "chorus")
+	s.RingBuffer.WriteAt(second, t0.Add(2*time.Second))
+
+	bm, ok := s.Bookmark("b1")
+	if !ok {
+		t.Fatal("bookmark not found")
+	}
+	if bm != added || bm.Offset != 2*ringbuffer.BytesPerSecond || bm.Label != "chorus" ||
+		!bm.At.Equal(t0.Add(2*time.Second)) {
+		t.Errorf("bookmark = %+v", bm)
+	}
+
+	// Re-using an ID moves the bookmark.
+	s.AddBookmark("b1", "verse")
+	if bm, _ := s.Bookmark("b1"); bm.Offset != 3*ringbuffer.BytesPerSecond || bm.Label != "verse" {
+		t.Errorf("replaced bookmark = %+v", bm)
+	}
+}
+
+func TestBookmarkUnknown(t *testing.T) {
+	s := newTestSession(t)
+	s.AddBookmark("b1", "")
+	if _, ok := s.Bookmark("b2"); ok {
+		t.Error("unknown bookmark found")
+	}
+}
+
+func TestBookmarkEvicted(t *testing.T) {
+	s := newTestSession(t) // 5s ring buffer
+	second := make([]byte, ringbuffer.BytesPerSecond)
+	s.RingBuffer.Write(second)
+	s.AddBookmark("old", "")
+	s.RingBuffer.Write(second)
+	s.AddBookmark("new", "")
+
+	// 5s more overwrites the audio at "old" but not yet at "new".
+	for i := 0; i < 5; i++ {
+		s.RingBuffer.Write(second)
+	}
+	if _, ok := s.Bookmark("old"); ok {
+		t.Error("bookmark on overwritten audio still found")
+	}
+	if _, ok := s.Bookmark("new"); !ok {
+		t.Error("bookmark on buffered audio evicted")
+	}
+}
+
+func TestBookmarkLimit(t *testing.T) {
+	s := newTestSession(t)
+	for i := 0; i <= maxBookmarks; i++ {
+		s.AddBookmark(fmt.Sprintf("b%d", i), "")
+	}
+	if _, ok := s.Bookmark("b0"); ok {
+		t.Error("oldest bookmark kept past the limit")
+	}
+	if _, ok := s.Bookmark("b1"); !ok {
+		t.Error("second bookmark dropped")
+	}
+}